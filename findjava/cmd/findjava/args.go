@@ -10,28 +10,177 @@ import (
 	"os"
 )
 
+const commandFind = "find"
+const commandList = "list"
+const commandExec = "exec"
+const commandEnv = "env"
+const commandDoctor = "doctor"
+const commandCache = "cache"
+
+const cacheActionShow = "show"
+const cacheActionClear = "clear"
+const cacheActionRefresh = "refresh"
+const cacheActionRebuild = "rebuild"
+
 const outputModeBinary = "binary"
 const outputModeJavaHome = "java.home"
+const outputModeJson = "json"
+const outputModeTable = "table"
+const outputModeSh = "sh"
+const outputModeFish = "fish"
+const outputModeDotenv = "dotenv"
+const outputModeSystemd = "systemd"
+
+type command struct {
+	name        string
+	usage       string
+	description string
+}
+
+var commands = []command{
+	{commandFind, "find [OPTIONS]", "Finds the JVM matching the requirements (default)"},
+	{commandList, "list [OPTIONS]", "Lists all the discovered JVMs"},
+	{commandExec, "exec [OPTIONS] -- [ARGS...]",
+		"Executes the first program of the JVM matching the requirements with the given arguments"},
+	{commandEnv, "env [OPTIONS]", "Prints JAVA_HOME and PATH environment variables for the JVM matching the requirements"},
+	{commandDoctor, "doctor [OPTIONS]", "Checks the findjava installation and configuration"},
+	{commandCache, "cache show|clear|refresh|rebuild [OPTIONS]",
+		"Shows the cached JVMs metadata, deletes the cache, fetches again the metadata of every discovered JVM " +
+			"or rebuilds the cache from scratch"},
+}
 
 type Args struct {
+	Command        string
 	version        bool
 	logLevel       string
 	ConfigKey      string
 	MinJavaVersion uint
 	MaxJavaVersion uint
+	JavaVersion    *VersionExpression
+	MinJavaUpdates []JavaVersion
 	Vendors        utils.List
 	Programs       utils.List
+	Archs          utils.List
+	DataModel      uint
+	Properties     []*PropertyConstraint
+	Filters        []*FilterExpression
 	OutputMode     string
+	ShowSelection  bool
+	Explain        bool
+	NoCache        bool
+	RefreshCache   bool
+	CacheAction    string
+	SystemCache    bool
+	ExecArgs       []string
+}
+
+// versionExpressionValue is a flag.Value parsing its value as a VersionExpression.
+type versionExpressionValue struct {
+	expression **VersionExpression
+}
+
+func (value versionExpressionValue) String() string {
+	if value.expression == nil || *value.expression == nil {
+		return ""
+	}
+	return (*value.expression).String()
+}
+
+func (value versionExpressionValue) Set(expression string) error {
+	parsed, err := ParseVersionExpression(expression)
+	if err != nil {
+		return err
+	}
+	*value.expression = parsed
+	return nil
+}
+
+// javaVersionsValue is a repeatable flag.Value parsing its values as JavaVersion.
+type javaVersionsValue struct {
+	versions *[]JavaVersion
+}
+
+func (value javaVersionsValue) String() string {
+	if value.versions == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", *value.versions)
+}
+
+func (value javaVersionsValue) Set(version string) error {
+	parsed, err := ParseJavaVersion(version)
+	if err != nil {
+		return err
+	}
+	*value.versions = append(*value.versions, parsed)
+	return nil
+}
+
+// propertyConstraintsValue is a repeatable flag.Value parsing its values as PropertyConstraint.
+type propertyConstraintsValue struct {
+	constraints *[]*PropertyConstraint
+}
+
+func (value propertyConstraintsValue) String() string {
+	if value.constraints == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", *value.constraints)
+}
+
+func (value propertyConstraintsValue) Set(constraint string) error {
+	parsed, err := ParsePropertyConstraint(constraint)
+	if err != nil {
+		return err
+	}
+	*value.constraints = append(*value.constraints, parsed)
+	return nil
+}
+
+// filterExpressionsValue is a repeatable flag.Value parsing its values as FilterExpression.
+type filterExpressionsValue struct {
+	filters *[]*FilterExpression
+}
+
+func (value filterExpressionsValue) String() string {
+	if value.filters == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", *value.filters)
+}
+
+func (value filterExpressionsValue) Set(expression string) error {
+	parsed, err := ParseFilterExpression(expression)
+	if err != nil {
+		return err
+	}
+	*value.filters = append(*value.filters, parsed)
+	return nil
 }
 
 func ParseArgs(commandArgs []string) (*Args, error) {
-	args := Args{}
+	args := Args{Command: commandFind}
+	if len(commandArgs) > 0 && isCommand(commandArgs[0]) {
+		args.Command = commandArgs[0]
+		commandArgs = commandArgs[1:]
+	}
+	if args.Command == commandCache {
+		if len(commandArgs) == 0 || !isCacheAction(commandArgs[0]) {
+			return nil, fmt.Errorf("missing or invalid cache action. Available actions are: show, clear, refresh, rebuild")
+		}
+		args.CacheAction = commandArgs[0]
+		commandArgs = commandArgs[1:]
+	}
 	cmd := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
 	output := bytes.NewBufferString("")
 	cmd.SetOutput(output)
 	cmd.Usage = func() {
-		output.WriteString("Usage: findjava [OPTIONS]\n\n")
-		output.WriteString("Options:\n")
+		output.WriteString("Usage: findjava [COMMAND] [OPTIONS]\n\n")
+		output.WriteString("Commands:\n")
+		for _, command := range commands {
+			output.WriteString(fmt.Sprintf("  %s\n    \t%s\n", command.usage, command.description))
+		}
+		output.WriteString("\nOptions:\n")
 		cmd.PrintDefaults()
 	}
 	cmd.BoolVar(&args.version, "version", false, "Displays the version")
@@ -43,17 +192,64 @@ func ParseArgs(commandArgs []string) (*Args, error) {
 		"The minimum (inclusive) Java Language Specification version the found JVMs should provide")
 	cmd.UintVar(&args.MaxJavaVersion, "max-java-version", AllVersions,
 		"The maximum (inclusive) Java Language Specification version the found JVMs should provide")
+	cmd.Var(versionExpressionValue{&args.JavaVersion}, "java-version",
+		"A Java Language Specification version expression the found JVMs should match, "+
+			"for example \"[11,17)\", \"11..21,!13\", \">=17\" or \"8|11|17|21\". "+
+			"Cannot be combined with --min-java-version and --max-java-version")
+	cmd.Var(javaVersionsValue{&args.MinJavaUpdates}, "min-java-update",
+		"(repeatable) The minimum (inclusive) full Java version, for example \"17.0.9\" or \"17.0.9+\". "+
+			"Only applies to the JVMs of the same feature version, other JVMs are not constrained")
 	cmd.Var(&args.Vendors, "vendors",
 		"The vendors to filter on. If empty, no vendor filtering will be done")
 	cmd.Var(&args.Programs, "programs",
 		"The programs the JVM should provide in its \"${java.home}/bin\" directory. If empty, defaults to java")
-	cmd.StringVar(&args.OutputMode, "output-mode", outputModeBinary,
-		"The output mode of findjava. Possible values are \"java.home\" (the home directory of the selected JVM) "+
-			"and \"binary\" (the path to the desired binary of the selected JVM). If not specified, defaults to binary")
+	cmd.Var(&args.Archs, "arch",
+		"(repeatable) The CPU architectures (os.arch) to filter on, for example \"amd64\" or \"aarch64\". "+
+			"If empty, JVMs matching the host architecture are preferred")
+	cmd.UintVar(&args.DataModel, "data-model", 0,
+		"The data model (sun.arch.data.model) to filter on, 32 or 64. If not specified, no data model filtering will be done")
+	cmd.Var(propertyConstraintsValue{&args.Properties}, "require-property",
+		"(repeatable) A constraint the system properties of the JVMs should satisfy: \"KEY=VALUE\", \"KEY!=VALUE\", "+
+			"\"KEY~=REGEX\" or \"KEY!~=REGEX\", for example \"java.vm.name~=OpenJ9\"")
+	cmd.Var(filterExpressionsValue{&args.Filters}, "filter",
+		"(repeatable) A filter expression the JVMs should match, for example "+
+			"'spec >= 17 && vendor in [\"Eclipse Adoptium\"] && !(prop(\"java.vm.name\") ~= \"Zero\")'")
+	if args.Command != commandDoctor && args.Command != commandCache {
+		cmd.BoolVar(&args.NoCache, "no-cache", false,
+			"Fetches the metadata of every discovered JVM without reading nor updating the cache")
+		cmd.BoolVar(&args.RefreshCache, "refresh", false,
+			"Fetches again the metadata of every discovered JVM and updates the cache")
+	}
+	if args.Command == commandCache {
+		cmd.BoolVar(&args.SystemCache, "system", false,
+			"Applies the cache action to the read-only system cache instead of the user's cache. "+
+				"Writing the system cache usually requires root privileges")
+	}
+	if args.Command == commandList {
+		cmd.StringVar(&args.OutputMode, "output-mode", outputModeTable,
+			"The output mode of the list command. Possible values are \"table\" (a human readable table) "+
+				"and \"json\" (a JSON document describing every JVM). If not specified, defaults to table")
+		cmd.BoolVar(&args.ShowSelection, "show-selection", false,
+			"Applies the selection rules and marks each JVM as candidate, ignored or selected")
+	} else if args.Command == commandEnv {
+		cmd.StringVar(&args.OutputMode, "output-mode", outputModeSh,
+			"The output mode of the env command. Possible values are \"sh\" (POSIX sh, bash, zsh), \"fish\", "+
+				"\"dotenv\" (a .env file) and \"systemd\" (a systemd EnvironmentFile). If not specified, defaults to sh")
+	} else if args.Command == commandFind {
+		cmd.StringVar(&args.OutputMode, "output-mode", outputModeBinary,
+			"The output mode of findjava. Possible values are \"java.home\" (the home directory of the selected JVM), "+
+				"\"binary\" (the path to the desired binary of the selected JVM) "+
+				"and \"json\" (a JSON document describing the selected JVM). If not specified, defaults to binary")
+		cmd.BoolVar(&args.Explain, "explain", false,
+			"Prints a report explaining why each JVM has been selected or ignored instead of the selected JVM. "+
+				"The report is a JSON document when used with --output-mode=json")
+	}
 	if err := cmd.Parse(commandArgs); err != nil {
 		return nil, fmt.Errorf("%s\n%s", err, output)
 	}
-	if unresolvedArgs := cmd.Args(); len(unresolvedArgs) > 0 {
+	if args.Command == commandExec {
+		args.ExecArgs = cmd.Args()
+	} else if unresolvedArgs := cmd.Args(); len(unresolvedArgs) > 0 {
 		cmd.Usage()
 		return nil, fmt.Errorf("unresolved arguments: %v\n%s", unresolvedArgs, output)
 	}
@@ -63,14 +259,66 @@ func ParseArgs(commandArgs []string) (*Args, error) {
 	if len(args.Programs) == 0 {
 		args.Programs = append(args.Programs, "java")
 	}
+	if args.JavaVersion != nil && (args.MinJavaVersion != AllVersions || args.MaxJavaVersion != AllVersions) {
+		return nil, fmt.Errorf("--java-version cannot be combined with --min-java-version or --max-java-version")
+	}
+	if args.DataModel != 0 && args.DataModel != 32 && args.DataModel != 64 {
+		return nil, fmt.Errorf("invalid data model: %d. Available values are: 32, 64", args.DataModel)
+	}
+	if args.NoCache && args.RefreshCache {
+		return nil, fmt.Errorf("--no-cache and --refresh cannot be used together")
+	}
 	if err := validateOutputMode(args); err != nil {
 		return nil, err
 	}
 	return &args, nil
 }
 
+func isCommand(arg string) bool {
+	for _, command := range commands {
+		if arg == command.name {
+			return true
+		}
+	}
+	return false
+}
+
+func isCacheAction(arg string) bool {
+	return arg == cacheActionShow || arg == cacheActionClear || arg == cacheActionRefresh ||
+		arg == cacheActionRebuild
+}
+
+func (args *Args) cachePolicy() CachePolicy {
+	if args.NoCache {
+		return CacheDisabled
+	}
+	if args.RefreshCache || args.CacheAction == cacheActionRefresh {
+		return CacheRefresh
+	}
+	if args.CacheAction == cacheActionRebuild {
+		return CacheRebuild
+	}
+	return CacheEnabled
+}
+
 func validateOutputMode(args Args) error {
-	if args.OutputMode == outputModeJavaHome {
+	if args.Command == commandExec || args.Command == commandDoctor || args.Command == commandCache {
+		return nil
+	}
+	if args.Command == commandList {
+		if args.OutputMode == outputModeTable || args.OutputMode == outputModeJson {
+			return nil
+		}
+		return fmt.Errorf("invalid output mode: \"%s\". Available values are: table, json", args.OutputMode)
+	}
+	if args.Command == commandEnv {
+		switch args.OutputMode {
+		case outputModeSh, outputModeFish, outputModeDotenv, outputModeSystemd:
+			return nil
+		}
+		return fmt.Errorf("invalid output mode: \"%s\". Available values are: sh, fish, dotenv, systemd", args.OutputMode)
+	}
+	if args.OutputMode == outputModeJavaHome || args.OutputMode == outputModeJson {
 		return nil
 	} else if args.OutputMode == outputModeBinary {
 		if len(args.Programs) > 1 {
@@ -79,6 +327,6 @@ func validateOutputMode(args Args) error {
 		}
 		return nil
 	} else {
-		return fmt.Errorf("invalid output mode: \"%s\". Available values are: java.home, binary", args.OutputMode)
+		return fmt.Errorf("invalid output mode: \"%s\". Available values are: java.home, binary, json", args.OutputMode)
 	}
 }
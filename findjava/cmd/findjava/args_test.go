@@ -1,6 +1,7 @@
 package main
 
 import (
+	. "findjava/internal/jvm"
 	"findjava/test"
 	"fmt"
 	"testing"
@@ -13,6 +14,7 @@ func TestParseArgs(t *testing.T) {
 		err      error
 	}
 	defaults := Args{
+		Command:    "find",
 		logLevel:   "error",
 		Programs:   []string{"java"},
 		OutputMode: "binary",
@@ -53,6 +55,28 @@ func TestParseArgs(t *testing.T) {
 			args.logLevel = "error"
 			args.MaxJavaVersion = 17
 		}),
+	}, {
+		args: []string{"--min-java-update", "17.0.9+", "--min-java-update", "1.8.0_392"},
+		expected: patch(defaults, func(args *Args) {
+			args.logLevel = "error"
+			args.MinJavaUpdates = []JavaVersion{{Feature: 17, Update: 9}, {Feature: 8, Update: 392}}
+		}),
+	}, {
+		args: []string{"--require-property", "java.vm.name~=OpenJ9", "--require-property", "jdk.debug!=fastdebug"},
+		expected: patch(defaults, func(args *Args) {
+			args.logLevel = "error"
+			args.Properties = []*PropertyConstraint{
+				mustParsePropertyConstraint("java.vm.name~=OpenJ9"),
+				mustParsePropertyConstraint("jdk.debug!=fastdebug"),
+			}
+		}),
+	}, {
+		args: []string{"--arch", "aarch64", "--arch", "amd64", "--data-model", "64"},
+		expected: patch(defaults, func(args *Args) {
+			args.logLevel = "error"
+			args.Archs = []string{"aarch64", "amd64"}
+			args.DataModel = 64
+		}),
 	}, {
 		args: []string{"--vendors", "Eclipse Adoptium"},
 		expected: patch(defaults, func(args *Args) {
@@ -86,6 +110,120 @@ func TestParseArgs(t *testing.T) {
 		expected: patch(defaults, func(args *Args) {
 			args.OutputMode = "java.home"
 		}),
+	}, {
+		args: []string{"find", "--min-java-version", "11"},
+		expected: patch(defaults, func(args *Args) {
+			args.MinJavaVersion = 11
+		}),
+	}, {
+		args: []string{"list"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "list"
+			args.OutputMode = "table"
+		}),
+	}, {
+		args: []string{"list", "--show-selection", "--min-java-version", "17"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "list"
+			args.OutputMode = "table"
+			args.ShowSelection = true
+			args.MinJavaVersion = 17
+		}),
+	}, {
+		args: []string{"--output-mode", "json", "--programs", "java", "--programs", "javac"},
+		expected: patch(defaults, func(args *Args) {
+			args.OutputMode = "json"
+			args.Programs = []string{"java", "javac"}
+		}),
+	}, {
+		args: []string{"list", "--output-mode", "json"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "list"
+			args.OutputMode = "json"
+		}),
+	}, {
+		args: []string{"--explain", "--output-mode", "json"},
+		expected: patch(defaults, func(args *Args) {
+			args.Explain = true
+			args.OutputMode = "json"
+		}),
+	}, {
+		args: []string{"env", "--min-java-version=17"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "env"
+			args.OutputMode = "sh"
+			args.MinJavaVersion = 17
+		}),
+	}, {
+		args: []string{"env", "--output-mode=systemd"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "env"
+			args.OutputMode = "systemd"
+		}),
+	}, {
+		args: []string{"doctor", "--config-key=dpkg"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "doctor"
+			args.OutputMode = ""
+			args.ConfigKey = "dpkg"
+		}),
+	}, {
+		args: []string{"--java-version", "[11,17)|21"},
+		expected: patch(defaults, func(args *Args) {
+			args.JavaVersion, _ = ParseVersionExpression("[11,17)|21")
+		}),
+	}, {
+		args: []string{"--no-cache"},
+		expected: patch(defaults, func(args *Args) {
+			args.NoCache = true
+		}),
+	}, {
+		args: []string{"list", "--refresh"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "list"
+			args.OutputMode = "table"
+			args.RefreshCache = true
+		}),
+	}, {
+		args: []string{"cache", "show", "--config-key=dpkg"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "cache"
+			args.CacheAction = "show"
+			args.OutputMode = ""
+			args.ConfigKey = "dpkg"
+		}),
+	}, {
+		args: []string{"cache", "refresh"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "cache"
+			args.CacheAction = "refresh"
+			args.OutputMode = ""
+		}),
+	}, {
+		args: []string{"cache", "rebuild", "--system"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "cache"
+			args.CacheAction = "rebuild"
+			args.OutputMode = ""
+			args.SystemCache = true
+		}),
+	}, {
+		args: []string{"exec", "--min-java-version=17"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "exec"
+			args.OutputMode = ""
+			args.MinJavaVersion = 17
+			args.ExecArgs = []string{}
+		}),
+	}, {
+		args: []string{"exec", "--min-java-version=17", "--programs=javac", "--", "-jar", "app.jar"},
+		expected: patch(defaults, func(args *Args) {
+			args.Command = "exec"
+			args.OutputMode = ""
+			args.MinJavaVersion = 17
+			args.Programs = []string{"javac"}
+			args.ExecArgs = []string{"-jar", "app.jar"}
+		}),
 	}}
 	for _, data := range data {
 		actual, err := ParseArgs(data.args)
@@ -115,7 +253,51 @@ func TestParseArgsErrors(t *testing.T) {
 			"Use \"java.home\" instead",
 	}, {
 		args: []string{"--output-mode=xoxo"},
-		err:  "invalid output mode: \"xoxo\". Available values are: java.home, binary",
+		err:  "invalid output mode: \"xoxo\". Available values are: java.home, binary, json",
+	}, {
+		args: []string{"exec", "--output-mode=java.home"},
+		err:  "flag provided but not defined: -output-mode",
+	}, {
+		args: []string{"env", "--output-mode=json"},
+		err:  "invalid output mode: \"json\". Available values are: sh, fish, dotenv, systemd",
+	}, {
+		args: []string{"--java-version", "11..x"},
+		err:  "invalid version expression \"11..x\": invalid term \"11..x\": JVM version 'x' cannot be parsed",
+	}, {
+		args: []string{"--java-version", ">=11", "--max-java-version", "17"},
+		err:  "--java-version cannot be combined with --min-java-version or --max-java-version",
+	}, {
+		args: []string{"--min-java-update", "17.0.x"},
+		err:  "Java version '17.0.x' cannot be parsed",
+	}, {
+		args: []string{"--require-property", "java.vm.name"},
+		err: "invalid property constraint \"java.vm.name\": expecting KEY=VALUE, KEY!=VALUE, KEY~=REGEX or " +
+			"KEY!~=REGEX",
+	}, {
+		args: []string{"--filter", "spec >= 17 &&"},
+		err: "invalid value \"spec >= 17 &&\" for flag -filter: invalid filter expression: unexpected end of " +
+			"expression at column 14\n\tspec >= 17 &&\n\t             ^",
+	}, {
+		args: []string{"--data-model", "16"},
+		err:  "invalid data model: 16. Available values are: 32, 64",
+	}, {
+		args: []string{"cache"},
+		err:  "missing or invalid cache action. Available actions are: show, clear, refresh, rebuild",
+	}, {
+		args: []string{"cache", "purge"},
+		err:  "missing or invalid cache action. Available actions are: show, clear, refresh, rebuild",
+	}, {
+		args: []string{"cache", "clear", "--no-cache"},
+		err:  "flag provided but not defined: -no-cache",
+	}, {
+		args: []string{"list", "--system"},
+		err:  "flag provided but not defined: -system",
+	}, {
+		args: []string{"--no-cache", "--refresh"},
+		err:  "--no-cache and --refresh cannot be used together",
+	}, {
+		args: []string{"list", "--output-mode=binary"},
+		err:  "invalid output mode: \"binary\". Available values are: table, json",
 	}}
 	for _, data := range data {
 		actual, err := ParseArgs(data.args)
@@ -130,3 +312,25 @@ func patch(args Args, patchFunc func(args *Args)) Args {
 	patchFunc(&args)
 	return args
 }
+
+func mustParsePropertyConstraint(constraint string) *PropertyConstraint {
+	parsed, err := ParsePropertyConstraint(constraint)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func TestParseArgsWithFilters(t *testing.T) {
+	args, err := ParseArgs([]string{"--filter", "spec >= 17", "--filter", "!has(\"jdk.debug\")"})
+	test.AssertNoError(t, "ParseArgs()", err)
+	test.AssertEquals(t, "ParseArgs().Filters", "[spec >= 17 !has(\"jdk.debug\")]", fmt.Sprintf("%v", args.Filters))
+}
+
+func mustParseFilterExpression(expression string) *FilterExpression {
+	parsed, err := ParseFilterExpression(expression)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"findjava/internal/config"
+	"findjava/internal/console"
+	"findjava/internal/jvm"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+func cache(args *Args, platform *config.Platform) error {
+	switch args.CacheAction {
+	case cacheActionShow, cacheActionClear:
+		cfg, err := platform.LoadConfig(args.ConfigKey)
+		if err != nil {
+			return err
+		}
+		cachePath, _, err := cachePaths(args, cfg)
+		if err != nil {
+			return err
+		}
+		if args.CacheAction == cacheActionClear {
+			return jvm.ClearCache(cachePath)
+		}
+		showCache(cachePath)
+		return nil
+	case cacheActionRefresh, cacheActionRebuild:
+		_, jvmInfos, err := loadJvms(args, platform)
+		if err != nil {
+			return err
+		}
+		console.Writer.Printf("Refreshed the metadata of %d JVM(s)\n", len(jvmInfos.Jvms))
+		printBrokenJvmTable(allBrokenJvms(&jvmInfos))
+		return nil
+	}
+	return fmt.Errorf("unsupported cache action \"%s\"", args.CacheAction)
+}
+
+// cachePaths returns the path of the cache to read and write, and the path of the read-only system cache,
+// which is empty when the system cache is not used. With --system, the system cache is the one to write.
+func cachePaths(args *Args, cfg *config.Config) (string, string, error) {
+	if !args.SystemCache {
+		return cfg.JvmsMetadataCachePath, cfg.JvmsSystemMetadataCachePath, nil
+	}
+	if cfg.JvmsSystemMetadataCachePath == "" {
+		return "", "", fmt.Errorf("this build of findjava has no system cache")
+	}
+	return cfg.JvmsSystemMetadataCachePath, "", nil
+}
+
+func showCache(cachePath string) {
+	jvmInfos := jvm.LoadCache(cachePath)
+	var javaPaths []string
+	for javaPath := range jvmInfos.Jvms {
+		javaPaths = append(javaPaths, javaPath)
+	}
+	sort.Strings(javaPaths)
+	console.Writer.Printf("Cache: %s\n", cachePath)
+	console.Writer.Printf("Schema version: %d, findjava version: %s, extractor hash: %s, properties: %s\n\n",
+		jvmInfos.Header.SchemaVersion, jvmInfos.Header.FindjavaVersion, jvmInfos.Header.ExtractorHash,
+		jvmInfos.Header.Properties)
+	w := tabwriter.NewWriter(console.Writer.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "JAVA PATH\tJAVA HOME\tVERSION\tJAVA VERSION\tVENDOR\tARCH\tSTRATEGY\tFETCHED AT\tAGE\tSTALE")
+	for _, javaPath := range javaPaths {
+		j := jvmInfos.Jvms[javaPath]
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%t\n",
+			javaPath,
+			j.JavaHome,
+			j.JavaSpecificationVersion,
+			j.JavaVersion,
+			j.JavaVendor,
+			j.OsArch,
+			j.Strategy,
+			j.FetchedAt.Format(time.RFC3339),
+			time.Since(j.FetchedAt).Round(time.Second),
+			j.IsOutdated())
+	}
+	_ = w.Flush()
+	printBrokenJvmTable(allBrokenJvms(&jvmInfos))
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"findjava/internal/config"
+	"findjava/internal/console"
+	"findjava/internal/jvm"
+	"findjava/internal/utils"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type checklist struct {
+	failures int
+	warnings int
+}
+
+func (c *checklist) pass(message string, v ...interface{}) {
+	console.Writer.Printf("[PASS] %s\n", fmt.Sprintf(message, v...))
+}
+
+func (c *checklist) warn(message string, v ...interface{}) {
+	c.warnings++
+	console.Writer.Printf("[WARN] %s\n", fmt.Sprintf(message, v...))
+}
+
+func (c *checklist) fail(message string, v ...interface{}) {
+	c.failures++
+	console.Writer.Printf("[FAIL] %s\n", fmt.Sprintf(message, v...))
+}
+
+func (c *checklist) result() error {
+	console.Writer.Printf("\n%d failure(s), %d warning(s)\n", c.failures, c.warnings)
+	if c.failures > 0 {
+		return fmt.Errorf("findjava installation has %d failure(s)", c.failures)
+	}
+	return nil
+}
+
+func doctor(args *Args, platform *config.Platform) error {
+	checks := &checklist{}
+	if err := platform.Resolve(); err != nil {
+		checks.fail("platform resolution: %v", err)
+		return checks.result()
+	}
+	checks.pass("findjava binary: %s", platform.SelfPath)
+	checkDirectory(checks, "config directory", platform.ConfigDir, false)
+	checkDirectory(checks, "cache directory", platform.CacheDir, false)
+	checkMetadataExtractor(checks, platform.MetadataExtractorDir)
+	entries := checkConfigFiles(checks, platform, args.ConfigKey)
+	checkLookupPaths(checks, entries)
+	checkCache(checks, config.MetadataCachePath(platform.CacheDir))
+	if platform.SystemCacheDir != "" {
+		checkSystemCache(checks, config.MetadataCachePath(platform.SystemCacheDir))
+	}
+	return checks.result()
+}
+
+func checkDirectory(checks *checklist, description string, path string, required bool) bool {
+	report := checks.warn
+	if required {
+		report = checks.fail
+	}
+	if path == "" {
+		report("%s: not defined", description)
+		return false
+	}
+	if fileInfo, err := os.Stat(path); err != nil {
+		report("%s: %s does not exist", description, path)
+		return false
+	} else if !fileInfo.IsDir() {
+		checks.fail("%s: %s is not a directory", description, path)
+		return false
+	}
+	checks.pass("%s: %s", description, path)
+	return true
+}
+
+func checkMetadataExtractor(checks *checklist, directory string) {
+	classFile := filepath.Join(directory, jvm.MetadataExtractorClass+".class")
+	if fileInfo, err := os.Stat(classFile); err == nil {
+		if !fileInfo.Mode().IsRegular() {
+			checks.fail("metadata extractor: %s is not a regular file", classFile)
+		} else {
+			checks.pass("metadata extractor: %s (overrides the embedded one)", classFile)
+		}
+	} else if hash := jvm.EmbeddedExtractorHash(); hash != "" {
+		checks.pass("metadata extractor: embedded (sha256 %s)", hash)
+	} else {
+		checks.fail("metadata extractor: not embedded in findjava and %s does not exist", classFile)
+	}
+}
+
+// checkConfigFiles parses every configuration file of the config directory and returns the entries
+// which would be loaded for the given key, by order of precedence.
+func checkConfigFiles(checks *checklist, platform *config.Platform, key string) []config.ConfigEntry {
+	paths := platform.ConfigPaths(key)
+	if others, err := filepath.Glob(filepath.Join(platform.ConfigDir, "config.*.conf")); err == nil {
+		for _, other := range others {
+			if !contains(paths, other) {
+				paths = append(paths, other)
+			}
+		}
+	}
+	activePaths := platform.ConfigPaths(key)
+	var entries []config.ConfigEntry
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			if contains(activePaths, path) {
+				checks.pass("config file %s: not found, skipped", path)
+			}
+			continue
+		}
+		entry, err := config.LoadConfigFile(path)
+		if err != nil {
+			checks.fail("config file %s: %v", path, err)
+			continue
+		}
+		checks.pass("config file %s: valid", path)
+		if contains(activePaths, path) {
+			entries = append(entries, entry)
+		}
+	}
+	return append(entries, config.DefaultConfigEntry())
+}
+
+// checkLookupPaths checks the JVMs lookup paths the same way the configuration resolves them:
+// the first entry defining at least one resolvable path is used.
+func checkLookupPaths(checks *checklist, entries []config.ConfigEntry) {
+	for _, entry := range entries {
+		if len(entry.JvmLookupPaths) == 0 {
+			continue
+		}
+		resolved := 0
+		for _, path := range entry.JvmLookupPaths {
+			resolvedPath, err := utils.ResolvePath(path)
+			if err != nil {
+				checks.warn("jvm.lookup.paths %s: ignored, %v", path, err)
+			} else if _, err := os.Stat(resolvedPath); err != nil {
+				resolved++
+				checks.warn("jvm.lookup.paths %s: %s does not exist", path, resolvedPath)
+			} else {
+				resolved++
+				checks.pass("jvm.lookup.paths %s: %s", path, resolvedPath)
+			}
+		}
+		if resolved > 0 {
+			return
+		}
+		checks.warn("jvm.lookup.paths: none of %v can be resolved, falling back on the next configuration", entry.JvmLookupPaths)
+	}
+	checks.fail("jvm.lookup.paths: no lookup path can be resolved")
+}
+
+func checkCache(checks *checklist, path string) {
+	if _, err := os.Stat(path); err != nil {
+		directory := filepath.Dir(path)
+		if _, err := os.Stat(directory); err != nil {
+			checks.warn("cache file %s: does not exist yet and its directory will be created", path)
+			return
+		}
+		if err := checkWritableDirectory(directory); err != nil {
+			checks.fail("cache file %s: directory %s is not writable: %v", path, directory, err)
+		} else {
+			checks.pass("cache file %s: does not exist yet, directory is writable", path)
+		}
+		return
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		checks.fail("cache file %s: not readable: %v", path, err)
+		return
+	}
+	// The cache is replaced atomically by renaming a temporary file of its directory
+	if err := checkWritableDirectory(filepath.Dir(path)); err != nil {
+		checks.fail("cache file %s: not writable: %v", path, err)
+		return
+	}
+	if !json.Valid(content) {
+		checks.warn("cache file %s: corrupted, it will be rebuilt", path)
+		return
+	}
+	checks.pass("cache file %s: readable and writable", path)
+	checkBrokenJvms(checks, path)
+}
+
+// checkWritableDirectory checks that files can be created in the directory by creating and removing one.
+func checkWritableDirectory(directory string) error {
+	file, err := ioutil.TempFile(directory, ".doctor-")
+	if err != nil {
+		return err
+	}
+	_ = file.Close()
+	return os.Remove(file.Name())
+}
+
+func checkSystemCache(checks *checklist, path string) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		checks.warn("system cache file %s: does not exist, run 'findjava cache rebuild --system' as root "+
+			"to create it", path)
+		return
+	} else if err != nil {
+		checks.fail("system cache file %s: not readable: %v", path, err)
+		return
+	}
+	if !json.Valid(content) {
+		checks.warn("system cache file %s: corrupted, run 'findjava cache rebuild --system' as root "+
+			"to rebuild it", path)
+		return
+	}
+	checks.pass("system cache file %s: readable", path)
+}
+
+func checkBrokenJvms(checks *checklist, cachePath string) {
+	jvmInfos := jvm.LoadCache(cachePath)
+	for _, broken := range allBrokenJvms(&jvmInfos) {
+		checks.warn("JVM %s: broken since %s, skipped until its files change "+
+			"(run 'findjava cache refresh' to retry): %s", broken.JavaPath(),
+			broken.FailedAt.Format(time.RFC3339), broken.Error)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"findjava/internal/config"
+	"findjava/internal/console"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func env(args *Args, platform *config.Platform) error {
+	jvm, err := selectJvm(args, platform)
+	if err != nil {
+		return err
+	}
+	output, err := envOutput(args.OutputMode, jvm.JavaHome, os.Getenv("PATH"))
+	if err != nil {
+		return err
+	}
+	console.Writer.Printf("%s", output)
+	return nil
+}
+
+// envOutput formats the JAVA_HOME and PATH environment variables for the given output mode.
+// Shell formats reference the current $PATH while file formats embed the value of path
+// as they do not support variable expansion.
+func envOutput(outputMode string, javaHome string, path string) (string, error) {
+	bin := filepath.Join(javaHome, "bin")
+	switch outputMode {
+	case outputModeSh:
+		return fmt.Sprintf("export JAVA_HOME=%s\nexport PATH=%s\"${PATH:+:$PATH}\"\n",
+			shQuote(javaHome), shQuote(bin)), nil
+	case outputModeFish:
+		return fmt.Sprintf("set -gx JAVA_HOME %s\nset -gx PATH %s $PATH\n",
+			fishQuote(javaHome), fishQuote(bin)), nil
+	case outputModeDotenv, outputModeSystemd:
+		return fmt.Sprintf("JAVA_HOME=%s\nPATH=%s\n",
+			doubleQuote(javaHome), doubleQuote(prependPath(bin, path))), nil
+	}
+	return "", fmt.Errorf("unsupported output-mode \"%s\"", outputMode)
+}
+
+func prependPath(directory string, path string) string {
+	if path == "" {
+		return directory
+	}
+	return directory + string(os.PathListSeparator) + path
+}
+
+// shQuote quotes a value for POSIX shells using single quotes in which nothing is interpreted.
+func shQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes a value for fish in which only \ and ' must be escaped inside single quotes.
+func fishQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+}
+
+// doubleQuote quotes a value for .env and systemd EnvironmentFile files.
+func doubleQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value) + `"`
+}
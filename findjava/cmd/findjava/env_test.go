@@ -0,0 +1,45 @@
+package main
+
+import (
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestEnvOutput(t *testing.T) {
+	type TestData struct {
+		outputMode, javaHome, path, expected string
+	}
+	data := []TestData{{
+		outputMode: "sh",
+		javaHome:   "/usr/lib/jvm/java-17",
+		path:       "/usr/bin",
+		expected:   "export JAVA_HOME='/usr/lib/jvm/java-17'\nexport PATH='/usr/lib/jvm/java-17/bin'\"${PATH:+:$PATH}\"\n",
+	}, {
+		outputMode: "sh",
+		javaHome:   "/opt/it's java",
+		path:       "/usr/bin",
+		expected:   "export JAVA_HOME='/opt/it'\\''s java'\nexport PATH='/opt/it'\\''s java/bin'\"${PATH:+:$PATH}\"\n",
+	}, {
+		outputMode: "fish",
+		javaHome:   "/opt/it's java",
+		path:       "/usr/bin",
+		expected:   "set -gx JAVA_HOME '/opt/it\\'s java'\nset -gx PATH '/opt/it\\'s java/bin' $PATH\n",
+	}, {
+		outputMode: "dotenv",
+		javaHome:   "/usr/lib/jvm/java-17",
+		path:       "/usr/local/bin:/usr/bin",
+		expected:   "JAVA_HOME=\"/usr/lib/jvm/java-17\"\nPATH=\"/usr/lib/jvm/java-17/bin:/usr/local/bin:/usr/bin\"\n",
+	}, {
+		outputMode: "systemd",
+		javaHome:   "/opt/\"$java\"",
+		path:       "",
+		expected:   "JAVA_HOME=\"/opt/\\\"\\$java\\\"\"\nPATH=\"/opt/\\\"\\$java\\\"/bin\"\n",
+	}}
+	for _, data := range data {
+		actual, err := envOutput(data.outputMode, data.javaHome, data.path)
+		description := fmt.Sprintf("envOutput(\"%s\", \"%s\", \"%s\")", data.outputMode, data.javaHome, data.path)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description, data.expected, actual)
+	}
+}
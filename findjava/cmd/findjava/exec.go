@@ -0,0 +1,38 @@
+package main
+
+import (
+	"findjava/internal/config"
+	"findjava/internal/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+func execute(args *Args, platform *config.Platform) error {
+	jvm, err := selectJvm(args, platform)
+	if err != nil {
+		return err
+	}
+	program := filepath.Join(jvm.JavaHome, "bin", args.Programs[0])
+	argv := append([]string{program}, args.ExecArgs...)
+	env := withEnvVar(os.Environ(), "JAVA_HOME", jvm.JavaHome)
+	log.Debug("Executing %s with args %v", program, args.ExecArgs)
+	if err := syscall.Exec(program, argv, env); err != nil {
+		return log.WrapErr(err, "unable to execute %s", program)
+	}
+	return nil
+}
+
+// withEnvVar returns a copy of env in which the variable name is set to value,
+// replacing any previous definition of it.
+func withEnvVar(env []string, name string, value string) []string {
+	prefix := name + "="
+	result := make([]string, 0, len(env)+1)
+	for _, entry := range env {
+		if !strings.HasPrefix(entry, prefix) {
+			result = append(result, entry)
+		}
+	}
+	return append(result, prefix+value)
+}
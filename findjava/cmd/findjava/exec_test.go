@@ -0,0 +1,29 @@
+package main
+
+import (
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestWithEnvVar(t *testing.T) {
+	type TestData struct {
+		env      []string
+		expected []string
+	}
+	data := []TestData{{
+		env:      []string{},
+		expected: []string{"JAVA_HOME=/jvm"},
+	}, {
+		env:      []string{"PATH=/usr/bin", "HOME=/home/user"},
+		expected: []string{"PATH=/usr/bin", "HOME=/home/user", "JAVA_HOME=/jvm"},
+	}, {
+		env:      []string{"JAVA_HOME=/old/jvm", "PATH=/usr/bin", "JAVA_HOME_17=/jvm17"},
+		expected: []string{"PATH=/usr/bin", "JAVA_HOME_17=/jvm17", "JAVA_HOME=/jvm"},
+	}}
+	for _, data := range data {
+		actual := withEnvVar(data.env, "JAVA_HOME", "/jvm")
+		description := fmt.Sprintf("withEnvVar(%#v, \"JAVA_HOME\", \"/jvm\")", data.env)
+		test.AssertEquals(t, description, data.expected, actual)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"findjava/internal/config"
+	"findjava/internal/console"
+	"findjava/internal/jvm"
+	"findjava/internal/rules"
+	"findjava/internal/selection"
+	"strings"
+)
+
+func explain(args *Args, cfg *config.Config, rules *rules.JvmSelectionRules, jvmInfos *jvm.JvmsInfos) error {
+	report := selection.Explain(rules, jvmInfos)
+	var selected *jvm.Jvm
+	if len(report.Explanations) > 0 && report.Explanations[0].Status == selection.StatusSelected {
+		selected = &report.Explanations[0].Jvm
+	}
+	if args.OutputMode == outputModeJson {
+		if err := printJsonExplainReport(args, cfg, rules, report, selected); err != nil {
+			return err
+		}
+	} else {
+		printExplainReport(rules, report)
+	}
+	if selected == nil {
+		return noMatchingJvmError(rules)
+	}
+	return nil
+}
+
+func printExplainReport(rules *rules.JvmSelectionRules, report selection.Report) {
+	console.Writer.Printf("Selection rules:%s\n\n", rules)
+	if len(report.Explanations) == 0 {
+		console.Writer.Printf("No JVM discovered\n")
+	}
+	for _, explanation := range report.Explanations {
+		j := explanation.Jvm
+		console.Writer.Printf("%-12s %3d: %s (%s, %s, %s)\n", "["+strings.ToUpper(explanation.Status)+"]",
+			j.JavaSpecificationVersion, j.JavaHome, j.JavaVendor, j.JavaVersion, j.OsArch)
+		for _, reason := range explanation.Reasons {
+			console.Writer.Printf("%18s %s\n", "-", reason)
+		}
+	}
+	if report.PreferredRulesIgnored {
+		console.Writer.Printf("\nNo JVM satisfies the preferred rules from the configuration, they have been ignored\n")
+	}
+}
+
+func printJsonExplainReport(args *Args, cfg *config.Config, rules *rules.JvmSelectionRules, report selection.Report,
+	selected *jvm.Jvm) error {
+	document := jsonFindDocument{
+		SchemaVersion: jsonSchemaVersion,
+		ConfigKey:     args.ConfigKey,
+		Config:        toJsonConfig(cfg),
+		Rules:         toJsonRules(rules),
+		Explain: &jsonExplain{
+			PreferredRulesIgnored: report.PreferredRulesIgnored,
+			Jvms:                  make([]jsonJvm, 0, len(report.Explanations)),
+		},
+	}
+	if selected != nil {
+		jsonSelected := toJsonJvm(selected, "")
+		document.Jvm = &jsonSelected
+	}
+	for _, explanation := range report.Explanations {
+		jsonExplanation := toJsonJvm(&explanation.Jvm, explanation.Status)
+		jsonExplanation.Reasons = explanation.Reasons
+		document.Explain.Jvms = append(document.Explain.Jvms, jsonExplanation)
+	}
+	return printJson(document)
+}
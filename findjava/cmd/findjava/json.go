@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"findjava/internal/config"
+	"findjava/internal/console"
+	"findjava/internal/jvm"
+	"findjava/internal/rules"
+	"time"
+)
+
+// jsonSchemaVersion is the version of the JSON documents produced by the json output mode.
+// It must be incremented whenever a field is removed, renamed or changes meaning.
+// Adding new fields is considered backward compatible and does not require a new version.
+const jsonSchemaVersion = 1
+
+type jsonFindDocument struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	ConfigKey     string       `json:"configKey"`
+	Config        *jsonConfig  `json:"config"`
+	Rules         *jsonRules   `json:"rules"`
+	Jvm           *jsonJvm     `json:"jvm"`
+	Explain       *jsonExplain `json:"explain,omitempty"`
+}
+
+type jsonExplain struct {
+	PreferredRulesIgnored bool      `json:"preferredRulesIgnored"`
+	Jvms                  []jsonJvm `json:"jvms"`
+}
+
+type jsonListDocument struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	ConfigKey     string          `json:"configKey"`
+	Config        *jsonConfig     `json:"config"`
+	Rules         *jsonRules      `json:"rules,omitempty"`
+	Jvms          []jsonJvm       `json:"jvms"`
+	BrokenJvms    []jsonBrokenJvm `json:"brokenJvms"`
+}
+
+type jsonConfig struct {
+	MetadataExtractorPath string            `json:"metadataExtractorPath"`
+	MetadataCachePath     string            `json:"metadataCachePath"`
+	LookupPaths           []string          `json:"lookupPaths"`
+	VersionRange          *jsonVersionRange `json:"versionRange"`
+	PreferredArchs        []string          `json:"preferredArchs"`
+}
+
+type jsonJavaVersion struct {
+	Feature uint `json:"feature"`
+	Interim uint `json:"interim"`
+	Update  uint `json:"update"`
+	Patch   uint `json:"patch"`
+	Build   uint `json:"build"`
+}
+
+type jsonRules struct {
+	VersionRange   *jsonVersionRange `json:"versionRange"`
+	MinUpdates     []string          `json:"minUpdates"`
+	Vendors        []string          `json:"vendors"`
+	Programs       []string          `json:"programs"`
+	Archs          []string          `json:"archs"`
+	DataModel      uint              `json:"dataModel"`
+	Properties     []string          `json:"properties"`
+	Filters        []string          `json:"filters"`
+	PreferredRules *jsonRules        `json:"preferredRules,omitempty"`
+	PreferredArchs []string          `json:"preferredArchs"`
+}
+
+type jsonVersionRange struct {
+	Min        *uint  `json:"min"`
+	Max        *uint  `json:"max"`
+	Expression string `json:"expression,omitempty"`
+}
+
+type jsonJvm struct {
+	JavaPath                 string            `json:"javaPath"`
+	JavaHome                 string            `json:"javaHome"`
+	JavaSpecificationVersion uint              `json:"javaSpecificationVersion"`
+	JavaVersion              jsonJavaVersion   `json:"javaVersion"`
+	JavaVendor               string            `json:"javaVendor"`
+	OsArch                   string            `json:"osArch"`
+	DataModel                uint              `json:"dataModel"`
+	FetchedAt                time.Time         `json:"fetchedAt"`
+	MetadataStrategy         string            `json:"metadataStrategy"`
+	SystemProperties         map[string]string `json:"systemProperties"`
+	Status                   string            `json:"status,omitempty"`
+	Reasons                  []string          `json:"reasons,omitempty"`
+}
+
+type jsonBrokenJvm struct {
+	JavaPath string    `json:"javaPath"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+func toJsonConfig(cfg *config.Config) *jsonConfig {
+	return &jsonConfig{
+		MetadataExtractorPath: cfg.JvmsMetadataExtractorPath,
+		MetadataCachePath:     cfg.JvmsMetadataCachePath,
+		LookupPaths:           nonNil(cfg.JvmsLookupPaths),
+		VersionRange:          toJsonVersionRange(cfg.JvmVersionRange),
+		PreferredArchs:        nonNil(cfg.JvmPreferredArchs),
+	}
+}
+
+func toJsonRules(rules *rules.JvmSelectionRules) *jsonRules {
+	if rules == nil {
+		return nil
+	}
+	return &jsonRules{
+		VersionRange:   toJsonVersionRange(rules.VersionRange),
+		MinUpdates:     toJsonVersions(rules.MinUpdates),
+		Vendors:        nonNil(rules.Vendors),
+		Programs:       nonNil(rules.Programs),
+		Archs:          nonNil(rules.Archs),
+		DataModel:      rules.DataModel,
+		Properties:     toJsonPropertyConstraints(rules.Properties),
+		Filters:        toJsonFilters(rules.Filters),
+		PreferredRules: toJsonRules(rules.PreferredRules),
+		PreferredArchs: nonNil(rules.PreferredArchs),
+	}
+}
+
+func toJsonVersionRange(versions jvm.VersionMatcher) *jsonVersionRange {
+	jsonRange := &jsonVersionRange{}
+	if expression, ok := versions.(*jvm.VersionExpression); ok {
+		jsonRange.Expression = expression.String()
+		return jsonRange
+	}
+	versionRange, ok := versions.(*jvm.VersionRange)
+	if !ok || versionRange == nil {
+		return jsonRange
+	}
+	if versionRange.Min != jvm.AllVersions {
+		min := versionRange.Min
+		jsonRange.Min = &min
+	}
+	if versionRange.Max != jvm.AllVersions {
+		max := versionRange.Max
+		jsonRange.Max = &max
+	}
+	return jsonRange
+}
+
+func toJsonJvm(j *jvm.Jvm, status string) jsonJvm {
+	systemProperties := j.SystemProperties
+	if systemProperties == nil {
+		systemProperties = map[string]string{}
+	}
+	return jsonJvm{
+		JavaPath:                 j.JavaPath(),
+		JavaHome:                 j.JavaHome,
+		JavaSpecificationVersion: j.JavaSpecificationVersion,
+		JavaVersion: jsonJavaVersion{
+			Feature: j.JavaVersion.Feature,
+			Interim: j.JavaVersion.Interim,
+			Update:  j.JavaVersion.Update,
+			Patch:   j.JavaVersion.Patch,
+			Build:   j.JavaVersion.Build,
+		},
+		JavaVendor:       j.JavaVendor,
+		OsArch:           j.OsArch,
+		DataModel:        j.DataModel,
+		FetchedAt:        j.FetchedAt,
+		MetadataStrategy: string(j.Strategy),
+		SystemProperties: systemProperties,
+		Status:           status,
+	}
+}
+
+func toJsonVersions(versions []jvm.JavaVersion) []string {
+	result := make([]string, 0, len(versions))
+	for _, version := range versions {
+		result = append(result, version.String())
+	}
+	return result
+}
+
+func toJsonPropertyConstraints(constraints []*jvm.PropertyConstraint) []string {
+	result := make([]string, 0, len(constraints))
+	for _, constraint := range constraints {
+		result = append(result, constraint.String())
+	}
+	return result
+}
+
+func toJsonFilters(filters []*jvm.FilterExpression) []string {
+	result := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		result = append(result, filter.String())
+	}
+	return result
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func printJson(document interface{}) error {
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	console.Writer.Printf("%s\n", content)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"findjava/internal/config"
+	. "findjava/internal/jvm"
+	"findjava/internal/rules"
+	"findjava/test"
+	"testing"
+	"time"
+)
+
+func TestJsonFindDocument(t *testing.T) {
+	cfg := config.Config{
+		JvmsMetadataExtractorPath: "/usr/share/findjava/metadata-extractor",
+		JvmsMetadataCachePath:     "/home/user/.cache/findjava/findjava.json",
+		JvmsLookupPaths:           []string{"/usr/lib/jvm"},
+		JvmVersionRange:           &VersionRange{Min: 11},
+		JvmPreferredArchs:         []string{"aarch64"},
+		PreferredProperties:       []*PropertyConstraint{{Key: "java.vm.name", Operator: PropertyNotEquals, Value: "Zero"}},
+		RequiredFilter:            mustParseFilterExpression("!has(\"jdk.debug\")"),
+	}
+	selectionRules := rules.SelectionRules(&cfg, 17, AllVersions, nil, []string{"java"})
+	jvm := Jvm{
+		JavaHome:                 "/usr/lib/jvm/java-17",
+		JavaSpecificationVersion: 17,
+		JavaVersion:              JavaVersion{Feature: 17, Update: 9, Build: 9},
+		JavaVendor:               "Eclipse Adoptium",
+		OsArch:                   "aarch64",
+		DataModel:                64,
+		FetchedAt:                time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+		Strategy:                 ReleaseFileStrategy,
+		SystemProperties:         map[string]string{"java.home": "/usr/lib/jvm/java-17"},
+	}
+	selected := toJsonJvm(&jvm, "")
+	document := jsonFindDocument{
+		SchemaVersion: jsonSchemaVersion,
+		ConfigKey:     "dpkg",
+		Config:        toJsonConfig(&cfg),
+		Rules:         toJsonRules(selectionRules),
+		Jvm:           &selected,
+	}
+	actual, err := json.Marshal(document)
+	test.AssertNoError(t, "json.Marshal(document)", err)
+	expected := `{"schemaVersion":1,"configKey":"dpkg",` +
+		`"config":{"metadataExtractorPath":"/usr/share/findjava/metadata-extractor",` +
+		`"metadataCachePath":"/home/user/.cache/findjava/findjava.json","lookupPaths":["/usr/lib/jvm"],` +
+		`"versionRange":{"min":11,"max":null},"preferredArchs":["aarch64"]},` +
+		`"rules":{"versionRange":{"min":17,"max":null},"minUpdates":[],"vendors":[],"programs":["java"],"archs":[],"dataModel":0,` +
+		`"properties":[],"filters":["!has(\"jdk.debug\")"],"preferredRules":{"versionRange":{"min":11,"max":null},` +
+		`"minUpdates":[],"vendors":[],"programs":[],"archs":[],"dataModel":0,"properties":["java.vm.name!=Zero"],` +
+		`"filters":[],"preferredArchs":[]},"preferredArchs":["aarch64"]},` +
+		`"jvm":{"javaPath":"","javaHome":"/usr/lib/jvm/java-17","javaSpecificationVersion":17,` +
+		`"javaVersion":{"feature":17,"interim":0,"update":9,"patch":0,"build":9},` +
+		`"javaVendor":"Eclipse Adoptium","osArch":"aarch64","dataModel":64,"fetchedAt":"2023-05-01T10:00:00Z",` +
+		`"metadataStrategy":"release-file","systemProperties":{"java.home":"/usr/lib/jvm/java-17"}}}`
+	test.AssertEquals(t, "json.Marshal(document)", expected, string(actual))
+}
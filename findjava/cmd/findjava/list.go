@@ -0,0 +1,118 @@
+package main
+
+import (
+	"findjava/internal/config"
+	"findjava/internal/console"
+	"findjava/internal/jvm"
+	"findjava/internal/rules"
+	"findjava/internal/selection"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+func list(args *Args, platform *config.Platform) error {
+	cfg, jvmInfos, err := loadJvms(args, platform)
+	if err != nil {
+		return err
+	}
+	jvms := allJvms(&jvmInfos)
+	var rules *rules.JvmSelectionRules
+	var statuses map[string]string
+	if args.ShowSelection {
+		rules = selectionRules(args, cfg)
+		statuses = selectionStatuses(rules, &jvmInfos)
+	}
+	brokenJvms := allBrokenJvms(&jvmInfos)
+	if args.OutputMode == outputModeJson {
+		return printJvmJson(args, cfg, rules, jvms, brokenJvms, statuses)
+	}
+	printJvmTable(jvms, statuses)
+	printBrokenJvmTable(brokenJvms)
+	return nil
+}
+
+func allJvms(jvmInfos *jvm.JvmsInfos) []jvm.Jvm {
+	var jvms []jvm.Jvm
+	for _, j := range jvmInfos.Jvms {
+		jvms = append(jvms, *j)
+	}
+	selection.Sort(jvms)
+	return jvms
+}
+
+func allBrokenJvms(jvmInfos *jvm.JvmsInfos) []*jvm.BrokenJvm {
+	var brokenJvms []*jvm.BrokenJvm
+	for _, broken := range jvmInfos.BrokenJvms {
+		brokenJvms = append(brokenJvms, broken)
+	}
+	sort.Slice(brokenJvms, func(i, j int) bool {
+		return brokenJvms[i].JavaPath() < brokenJvms[j].JavaPath()
+	})
+	return brokenJvms
+}
+
+func selectionStatuses(rules *rules.JvmSelectionRules, jvmInfos *jvm.JvmsInfos) map[string]string {
+	statuses := make(map[string]string)
+	for _, explanation := range selection.Explain(rules, jvmInfos).Explanations {
+		statuses[explanation.Jvm.JavaPath()] = explanation.Status
+	}
+	return statuses
+}
+
+func printJvmTable(jvms []jvm.Jvm, statuses map[string]string) {
+	w := tabwriter.NewWriter(console.Writer.Stdout, 0, 4, 2, ' ', 0)
+	header := "JAVA PATH\tJAVA HOME\tVERSION\tJAVA VERSION\tVENDOR\tARCH\tFETCHED AT"
+	if statuses != nil {
+		header += "\tSTATUS"
+	}
+	_, _ = fmt.Fprintln(w, header)
+	for _, j := range jvms {
+		row := fmt.Sprintf("%s\t%s\t%d\t%s\t%s\t%s\t%s", j.JavaPath(), j.JavaHome, j.JavaSpecificationVersion,
+			j.JavaVersion, j.JavaVendor, j.OsArch, j.FetchedAt.Format(time.RFC3339))
+		if statuses != nil {
+			row += "\t" + statuses[j.JavaPath()]
+		}
+		_, _ = fmt.Fprintln(w, row)
+	}
+	_ = w.Flush()
+}
+
+func printBrokenJvmTable(brokenJvms []*jvm.BrokenJvm) {
+	if len(brokenJvms) == 0 {
+		return
+	}
+	console.Writer.Printf("\nBroken JVMs, skipped until their files change:\n")
+	w := tabwriter.NewWriter(console.Writer.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "JAVA PATH\tFAILED AT\tERROR")
+	for _, broken := range brokenJvms {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", broken.JavaPath(), broken.FailedAt.Format(time.RFC3339),
+			broken.Error)
+	}
+	_ = w.Flush()
+}
+
+func printJvmJson(args *Args, cfg *config.Config, rules *rules.JvmSelectionRules, jvms []jvm.Jvm,
+	brokenJvms []*jvm.BrokenJvm, statuses map[string]string) error {
+	jsonJvms := make([]jsonJvm, 0, len(jvms))
+	for _, j := range jvms {
+		jsonJvms = append(jsonJvms, toJsonJvm(&j, statuses[j.JavaPath()]))
+	}
+	jsonBrokenJvms := make([]jsonBrokenJvm, 0, len(brokenJvms))
+	for _, broken := range brokenJvms {
+		jsonBrokenJvms = append(jsonBrokenJvms, jsonBrokenJvm{
+			JavaPath: broken.JavaPath(),
+			Error:    broken.Error,
+			FailedAt: broken.FailedAt,
+		})
+	}
+	return printJson(jsonListDocument{
+		SchemaVersion: jsonSchemaVersion,
+		ConfigKey:     args.ConfigKey,
+		Config:        toJsonConfig(cfg),
+		Rules:         toJsonRules(rules),
+		Jvms:          jsonJvms,
+		BrokenJvms:    jsonBrokenJvms,
+	})
+}
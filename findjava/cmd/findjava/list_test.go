@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"findjava/internal/console"
+	"findjava/internal/discovery"
+	"findjava/internal/jvm"
+	"findjava/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintBrokenJvmTable(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	javaPath := filepath.Join(directory, "broken-jdk", "bin", "java")
+	test.AssertNoError(t, "os.MkdirAll()", os.MkdirAll(filepath.Dir(javaPath), 0755))
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(javaPath, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	javaPaths := &discovery.JavaExecutables{JavaPaths: map[string]time.Time{javaPath: time.Unix(0, 0)}}
+	jvmInfos := jvm.LoadJvmsInfos(&jvm.MetadataReader{CacheDir: directory}, "", "", jvm.CacheDisabled, javaPaths)
+
+	var output bytes.Buffer
+	stdout := console.Writer.Stdout
+	console.Writer.Stdout = &output
+	defer func() { console.Writer.Stdout = stdout }()
+	printBrokenJvmTable(allBrokenJvms(&jvmInfos))
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	test.AssertEquals(t, "printBrokenJvmTable() lines", 3, len(lines))
+	line := lines[2]
+	expected := "unable to fetch the metadata of " + javaPath + " with any strategy: release-file: open " +
+		filepath.Join(directory, "broken-jdk", "release") + ": no such file or directory; extractor: "
+	if !strings.HasPrefix(line, javaPath) || !strings.Contains(line, expected) || strings.Contains(line, "::") {
+		t.Fatalf("Expecting the broken JVM line to contain %q but was %q", expected, line)
+	}
+}
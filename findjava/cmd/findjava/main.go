@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"findjava/internal/config"
 	"findjava/internal/console"
 	"findjava/internal/discovery"
@@ -16,15 +17,26 @@ import (
 
 var Version = "dev"
 
+// Exit codes of findjava, they are documented in the README and must not be changed.
+const (
+	exitCodeError         = 1
+	exitCodeInvalidArgs   = 2
+	exitCodeConfig        = 3
+	exitCodePlatform      = 4
+	exitCodeExtractor     = 5
+	exitCodeNoMatchingJvm = 6
+)
+
 func main() {
 	args, err := ParseArgs(os.Args[1:])
 	if err != nil {
-		log.Die(err)
+		log.Exit(err, exitCodeInvalidArgs)
 	}
 	platform := config.Platform{
 		ConfigDir:            linker.ConfigDir,
 		CacheDir:             linker.CacheDir,
 		MetadataExtractorDir: linker.MetadataExtractorDir,
+		SystemCacheDir:       linker.SystemCacheDir,
 	}
 	if args.version {
 		console.Writer.Printf("findjava %s\n", Version)
@@ -34,32 +46,179 @@ func main() {
 	if args == nil {
 		os.Exit(0)
 	}
-	cfg, err := platform.LoadConfig(args.ConfigKey)
-	if err != nil {
-		log.Die(err)
+	switch args.Command {
+	case commandList:
+		err = list(args, &platform)
+	case commandExec:
+		err = execute(args, &platform)
+	case commandEnv:
+		err = env(args, &platform)
+	case commandDoctor:
+		err = doctor(args, &platform)
+	case commandCache:
+		err = cache(args, &platform)
+	default:
+		err = find(args, &platform)
 	}
-	javaExecutables, err := discovery.FindAllJavaExecutables(&cfg.JvmsLookupPaths)
 	if err != nil {
-		log.Die(err)
+		log.Exit(err, exitCode(err))
+	}
+}
+
+// InvalidArgsError is returned when the arguments are inconsistent with the configuration.
+type InvalidArgsError struct {
+	Err error
+}
+
+func (e *InvalidArgsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidArgsError) Unwrap() error {
+	return e.Err
+}
+
+func exitCode(err error) int {
+	var invalidArgsError *InvalidArgsError
+	var configError *config.ConfigError
+	var platformError *config.PlatformError
+	var extractorError *jvm.ExtractorError
+	var noMatchError *selection.NoMatchingJvmError
+	switch {
+	case errors.As(err, &noMatchError):
+		return exitCodeNoMatchingJvm
+	case errors.As(err, &extractorError):
+		return exitCodeExtractor
+	case errors.As(err, &platformError):
+		return exitCodePlatform
+	case errors.As(err, &configError):
+		return exitCodeConfig
+	case errors.As(err, &invalidArgsError):
+		return exitCodeInvalidArgs
+	default:
+		return exitCodeError
 	}
-	metaDataFetcher := &jvm.MetadataReader{Classpath: cfg.JvmsMetadataExtractorPath}
-	jvmInfos, err := jvm.LoadJvmsInfos(metaDataFetcher, cfg.JvmsMetadataCachePath, &javaExecutables)
+}
+
+func find(args *Args, platform *config.Platform) error {
+	cfg, jvmInfos, err := loadUsableJvms(args, platform)
 	if err != nil {
-		log.Die(err)
+		return err
+	}
+	rules := selectionRules(args, cfg)
+	if args.Explain {
+		return explain(args, cfg, rules, &jvmInfos)
 	}
-	rules := rules.SelectionRules(cfg, args.MinJavaVersion, args.MaxJavaVersion, args.Vendors, args.Programs)
 	if jvms := selection.Select(rules, &jvmInfos); len(jvms) > 0 {
 		jvm := jvms[0]
 		selection.LogJvmList("[SELECTED]", jvms[0:1])
-		if err := processOutput(args, jvm); err != nil {
-			log.Die(err)
+		return processOutput(args, cfg, rules, jvm)
+	}
+	return noMatchingJvmError(rules)
+}
+
+// selectJvm runs the whole selection pipeline and returns the selected JVM.
+func selectJvm(args *Args, platform *config.Platform) (*jvm.Jvm, error) {
+	cfg, jvmInfos, err := loadUsableJvms(args, platform)
+	if err != nil {
+		return nil, err
+	}
+	rules := selectionRules(args, cfg)
+	jvms := selection.Select(rules, &jvmInfos)
+	if len(jvms) == 0 {
+		return nil, noMatchingJvmError(rules)
+	}
+	selection.LogJvmList("[SELECTED]", jvms[0:1])
+	return &jvms[0], nil
+}
+
+func noMatchingJvmError(rules *rules.JvmSelectionRules) error {
+	return &selection.NoMatchingJvmError{Rules: rules}
+}
+
+func loadJvms(args *Args, platform *config.Platform) (*config.Config, jvm.JvmsInfos, error) {
+	cfg, err := platform.LoadConfig(args.ConfigKey)
+	if err != nil {
+		return nil, jvm.JvmsInfos{}, err
+	}
+	if err := checkExtractedProperties(args, cfg); err != nil {
+		return nil, jvm.JvmsInfos{}, err
+	}
+	cachePath, systemCachePath, err := cachePaths(args, cfg)
+	if err != nil {
+		return nil, jvm.JvmsInfos{}, err
+	}
+	javaExecutables, err := discovery.FindAllJavaExecutables(&cfg.JvmsLookupPaths)
+	if err != nil {
+		return nil, jvm.JvmsInfos{}, err
+	}
+	metaDataFetcher := metadataReader(args, cfg, cachePath)
+	jvmInfos := jvm.LoadJvmsInfos(metaDataFetcher, cachePath, systemCachePath, args.cachePolicy(),
+		&javaExecutables)
+	return cfg, jvmInfos, nil
+}
+
+// metadataReader returns the reader of the JVMs metadata written to cachePath. The embedded metadata extractor class
+// is materialized next to that cache, so that rebuilding the system cache never writes to the user's cache directory.
+func metadataReader(args *Args, cfg *config.Config, cachePath string) *jvm.MetadataReader {
+	return &jvm.MetadataReader{
+		Classpath:   cfg.JvmsMetadataExtractorPath,
+		CacheDir:    filepath.Dir(cachePath),
+		Properties:  cfg.ExtractedProperties,
+		Version:     Version,
+		Concurrency: cfg.ExtractorConcurrency,
+		Timeout:     cfg.ExtractorTimeout,
+		// Launch the JVMs when the release files cannot tell whether they match the rules
+		ReferencedProperties: selectionRules(args, cfg).ReferencedProperties(),
+	}
+}
+
+// loadUsableJvms loads the JVMs metadata like loadJvms, failing when every discovered JVM is broken.
+func loadUsableJvms(args *Args, platform *config.Platform) (*config.Config, jvm.JvmsInfos, error) {
+	cfg, jvmInfos, err := loadJvms(args, platform)
+	if err != nil {
+		return nil, jvm.JvmsInfos{}, err
+	}
+	if err := jvmInfos.CheckUsableJvms(); err != nil {
+		return nil, jvm.JvmsInfos{}, err
+	}
+	return cfg, jvmInfos, nil
+}
+
+// checkExtractedProperties fails when the property constraints or the filter expressions of the arguments use
+// system properties which are not extracted, as they would never be defined.
+func checkExtractedProperties(args *Args, cfg *config.Config) error {
+	for _, constraint := range args.Properties {
+		if unextracted := jvm.UnextractedProperties(cfg.ExtractedProperties, []string{constraint.Key}); len(unextracted) > 0 {
+			return &InvalidArgsError{Err: fmt.Errorf("--require-property %s uses the system property %s which is not "+
+				"extracted, add it to metadata.extractor.properties", constraint, constraint.Key)}
 		}
+	}
+	for _, filter := range args.Filters {
+		if unextracted := jvm.UnextractedProperties(cfg.ExtractedProperties, filter.Properties()); len(unextracted) > 0 {
+			return &InvalidArgsError{Err: fmt.Errorf("--filter '%s' uses the system properties %v which are not "+
+				"extracted, add them to metadata.extractor.properties", filter, unextracted)}
+		}
+	}
+	return nil
+}
+
+func selectionRules(args *Args, cfg *config.Config) *rules.JvmSelectionRules {
+	var selectionRules *rules.JvmSelectionRules
+	if args.JavaVersion != nil {
+		selectionRules = rules.SelectionRulesWithVersions(cfg, args.JavaVersion, args.Vendors, args.Programs)
 	} else {
-		log.Die(fmt.Errorf("unable to find a JVM matching requirements %s", rules))
+		selectionRules = rules.SelectionRules(cfg, args.MinJavaVersion, args.MaxJavaVersion, args.Vendors, args.Programs)
 	}
+	selectionRules.MinUpdates = args.MinJavaUpdates
+	selectionRules.Archs = args.Archs
+	selectionRules.DataModel = args.DataModel
+	selectionRules.Properties = append(selectionRules.Properties, args.Properties...)
+	selectionRules.Filters = append(selectionRules.Filters, args.Filters...)
+	return selectionRules
 }
 
-func processOutput(args *Args, jvm jvm.Jvm) error {
+func processOutput(args *Args, cfg *config.Config, rules *rules.JvmSelectionRules, jvm jvm.Jvm) error {
 	if args.OutputMode == outputModeJavaHome {
 		console.Writer.Printf("%s\n", jvm.JavaHome)
 		return nil
@@ -70,5 +229,15 @@ func processOutput(args *Args, jvm jvm.Jvm) error {
 		}
 		return nil
 	}
+	if args.OutputMode == outputModeJson {
+		selected := toJsonJvm(&jvm, "")
+		return printJson(jsonFindDocument{
+			SchemaVersion: jsonSchemaVersion,
+			ConfigKey:     args.ConfigKey,
+			Config:        toJsonConfig(cfg),
+			Rules:         toJsonRules(rules),
+			Jvm:           &selected,
+		})
+	}
 	return fmt.Errorf("unsupported output-mode \"%s\"", args.OutputMode)
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"findjava/internal/config"
+	"findjava/internal/jvm"
+	"findjava/internal/log"
+	"findjava/internal/selection"
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	type TestData struct {
+		err      error
+		expected int
+	}
+	cause := errors.New("cause")
+	data := []TestData{{
+		err:      cause,
+		expected: 1,
+	}, {
+		err:      &InvalidArgsError{Err: cause},
+		expected: 2,
+	}, {
+		err:      &config.ConfigError{Err: cause},
+		expected: 3,
+	}, {
+		err:      &config.PlatformError{Err: cause},
+		expected: 4,
+	}, {
+		err:      log.WrapErr(&jvm.ExtractorError{JavaPath: "/usr/bin/java", Err: cause}, "unable to load JVMs"),
+		expected: 5,
+	}, {
+		err: &jvm.ExtractorErrors{Errors: []error{
+			&jvm.ExtractorError{JavaPath: "/usr/lib/jvm/java-11/bin/java", Err: cause},
+			&jvm.ExtractorError{JavaPath: "/usr/lib/jvm/java-17/bin/java", Err: cause},
+		}},
+		expected: 5,
+	}, {
+		err:      fmt.Errorf("wrapped: %w", &selection.NoMatchingJvmError{}),
+		expected: 6,
+	}}
+	for _, data := range data {
+		actual := exitCode(data.err)
+		description := fmt.Sprintf("exitCode(%#v)", data.err)
+		test.AssertEquals(t, description, data.expected, actual)
+	}
+}
+
+func TestCheckExtractedProperties(t *testing.T) {
+	type TestData struct {
+		args      []string
+		extracted []string
+		expected  string
+	}
+	data := []TestData{{
+		args:     []string{"--require-property", "java.vm.name~=OpenJ9", "--filter", `prop("os.arch") == "amd64"`},
+		expected: "",
+	}, {
+		args:      []string{"--require-property", "jdk.debug=release", "--filter", `!has("jdk.debug")`},
+		extracted: []string{"java.*", "jdk.debug"},
+		expected:  "",
+	}, {
+		args:     []string{"--require-property", "jdk.debug!=fastdebug"},
+		expected: "--require-property jdk.debug!=fastdebug uses the system property jdk.debug which is not extracted",
+	}, {
+		args:     []string{"--filter", `spec >= 17 && !has("jdk.debug")`},
+		expected: `--filter 'spec >= 17 && !has("jdk.debug")' uses the system properties [jdk.debug] which are not extracted`,
+	}}
+	for _, data := range data {
+		args, err := ParseArgs(data.args)
+		test.AssertNoError(t, fmt.Sprintf("ParseArgs(%v)", data.args), err)
+		err = checkExtractedProperties(args, &config.Config{ExtractedProperties: data.extracted})
+		description := fmt.Sprintf("checkExtractedProperties(%v, %v)", data.args, data.extracted)
+		if data.expected == "" {
+			test.AssertNoError(t, description, err)
+			continue
+		}
+		test.AssertErrorContains(t, description, data.expected, err)
+		test.AssertEquals(t, description+" exit code", exitCodeInvalidArgs, exitCode(err))
+	}
+}
+
+func TestMetadataReaderCacheDir(t *testing.T) {
+	cfg := &config.Config{
+		JvmsMetadataCachePath:       "/home/user/.cache/findjava/findjava.json",
+		JvmsSystemMetadataCachePath: "/var/cache/findjava/findjava.json",
+	}
+	for _, data := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"cache", "rebuild"}, "/home/user/.cache/findjava"},
+		{[]string{"cache", "rebuild", "--system"}, "/var/cache/findjava"},
+	} {
+		args, err := ParseArgs(data.args)
+		test.AssertNoError(t, fmt.Sprintf("ParseArgs(%v)", data.args), err)
+		cachePath, _, err := cachePaths(args, cfg)
+		test.AssertNoError(t, fmt.Sprintf("cachePaths(%v)", data.args), err)
+		test.AssertEquals(t, fmt.Sprintf("metadataReader(%v).CacheDir", data.args), data.expected,
+			metadataReader(args, cfg, cachePath).CacheDir)
+	}
+}
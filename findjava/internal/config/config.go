@@ -8,10 +8,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const defaultKey = ""
+const metadataCacheFileName = "findjava.json"
 
 var defaultConfigEntry = ConfigEntry{
 	path: "<DEFAULT>",
@@ -29,37 +32,121 @@ var defaultConfigEntry = ConfigEntry{
 		Min: 0,
 		Max: 0,
 	},
+	JvmPreferredArchs:   []string{hostArch},
+	ExtractedProperties: DefaultExtractedProperties,
 }
 
+// hostArch is the value of jvm.arch.preferred standing for the architecture findjava is running on.
+const hostArch = "host"
+
+// anyArch is the value of jvm.arch.preferred disabling the architecture preference.
+const anyArch = "any"
+
 type Config struct {
 	JvmsMetadataExtractorPath string
 	JvmsMetadataCachePath     string
-	JvmsLookupPaths           []string
-	JvmVersionRange           VersionRange
+	// JvmsSystemMetadataCachePath is the path of the read-only system-wide cache, empty when there is none.
+	JvmsSystemMetadataCachePath string
+	JvmsLookupPaths             []string
+	JvmVersionRange             VersionMatcher
+	JvmPreferredArchs           []string
+	// ExtractorConcurrency is the maximum number of JVMs launched at the same time to extract their
+	// metadata, zero meaning the number of CPUs.
+	ExtractorConcurrency int
+	// ExtractorTimeout is the time after which a JVM launched to extract its metadata is killed,
+	// zero meaning the default timeout.
+	ExtractorTimeout time.Duration
+	// ExtractedProperties are the patterns of the system properties extracted from the JVMs.
+	ExtractedProperties []string
+	// RequiredProperties are the constraints on the system properties every selected JVM must satisfy.
+	RequiredProperties []*PropertyConstraint
+	// PreferredProperties are the constraints on the system properties of the preferred JVMs.
+	PreferredProperties []*PropertyConstraint
+	// RequiredFilter is the filter expression every selected JVM must match, nil when there is none.
+	RequiredFilter *FilterExpression
+	// PreferredFilter is the filter expression of the preferred JVMs, nil when there is none.
+	PreferredFilter *FilterExpression
 }
 
 func (cfg *Config) String() string {
 	return fmt.Sprintf(`config:
 	JvmsMetadataExtractorPath :     %s
 	JvmsMetadataCachePath:          %s
+	JvmsSystemMetadataCachePath:    %s
 	JvmLookupPaths:                 %v
-	JvmVersionRange:                %s`, cfg.JvmsMetadataExtractorPath, cfg.JvmsMetadataCachePath, cfg.JvmsLookupPaths, &cfg.JvmVersionRange)
+	JvmVersionRange:                %s
+	JvmPreferredArchs:              %v
+	ExtractorConcurrency:           %d
+	ExtractorTimeout:               %s
+	ExtractedProperties:            %v
+	RequiredProperties:             %v
+	PreferredProperties:            %v
+	RequiredFilter:                 %v
+	PreferredFilter:                %v`, cfg.JvmsMetadataExtractorPath, cfg.JvmsMetadataCachePath,
+		cfg.JvmsSystemMetadataCachePath, cfg.JvmsLookupPaths,
+		cfg.JvmVersionRange, cfg.JvmPreferredArchs, cfg.ExtractorConcurrency, cfg.ExtractorTimeout,
+		cfg.ExtractedProperties, cfg.RequiredProperties, cfg.PreferredProperties, cfg.RequiredFilter,
+		cfg.PreferredFilter)
 }
 
 type ConfigEntry struct {
-	path            string
-	JvmLookupPaths  []string
-	JvmVersionRange *VersionRange
+	path                 string
+	JvmLookupPaths       []string
+	JvmVersionRange      *VersionRange
+	JvmVersionExpression *VersionExpression
+	JvmPreferredArchs    []string
+	// ExtractorConcurrency is nil when not defined by the configuration file.
+	ExtractorConcurrency *int
+	// ExtractorTimeout is nil when not defined by the configuration file.
+	ExtractorTimeout *time.Duration
+	// ExtractedProperties is nil when not defined by the configuration file.
+	ExtractedProperties []string
+	// RequiredProperties and PreferredProperties accumulate the values of every jvm.property.required and
+	// jvm.property.preferred line, they are nil when not defined by the configuration file.
+	RequiredProperties  []*PropertyConstraint
+	PreferredProperties []*PropertyConstraint
+	// RequiredFilter and PreferredFilter are nil when not defined by the configuration file.
+	RequiredFilter  *FilterExpression
+	PreferredFilter *FilterExpression
 }
 
 func (cfg ConfigEntry) String() string {
 	return fmt.Sprintf(`config entry:
-	path:               %s
-	JvmLookupPaths:     %v
-	JvmVersionRange:    %s`, cfg.path, cfg.JvmLookupPaths, cfg.JvmVersionRange)
+	path:                 %s
+	JvmLookupPaths:       %v
+	JvmVersionRange:      %s
+	JvmVersionExpression: %s
+	JvmPreferredArchs:    %v
+	ExtractorConcurrency: %v
+	ExtractorTimeout:     %v
+	ExtractedProperties:  %v
+	RequiredProperties:   %v
+	PreferredProperties:  %v
+	RequiredFilter:       %v
+	PreferredFilter:      %v`, cfg.path, cfg.JvmLookupPaths, cfg.JvmVersionRange, cfg.JvmVersionExpression,
+		cfg.JvmPreferredArchs, cfg.ExtractorConcurrency, cfg.ExtractorTimeout, cfg.ExtractedProperties,
+		cfg.RequiredProperties, cfg.PreferredProperties, cfg.RequiredFilter, cfg.PreferredFilter)
+}
+
+func (cfg ConfigEntry) versionMatcher() VersionMatcher {
+	if cfg.JvmVersionExpression != nil {
+		return cfg.JvmVersionExpression
+	}
+	if cfg.JvmVersionRange != nil {
+		return cfg.JvmVersionRange
+	}
+	return nil
 }
 
 func loadConfig(defaultConfigPath string, name string, cacheDir string, metadataExtractorDir string) (*Config, error) {
+	cfg, err := doLoadConfig(defaultConfigPath, name, cacheDir, metadataExtractorDir)
+	if err != nil {
+		return nil, &ConfigError{Err: err}
+	}
+	return cfg, nil
+}
+
+func doLoadConfig(defaultConfigPath string, name string, cacheDir string, metadataExtractorDir string) (*Config, error) {
 	var configs []ConfigEntry
 	configPaths := configPaths(name, defaultConfigPath)
 	for _, path := range configPaths {
@@ -89,14 +176,76 @@ func parseConfig(configs []ConfigEntry, cachePath string, extractorDir string) (
 	}
 	config := Config{
 		JvmsMetadataExtractorPath: extractorDir,
-		JvmsMetadataCachePath:     filepath.Join(cachePath, "findjava.json"),
+		JvmsMetadataCachePath:     MetadataCachePath(cachePath),
 		JvmsLookupPaths:           lookupPaths,
 		JvmVersionRange:           versionRange,
+		JvmPreferredArchs:         jvmPreferredArchs(configs),
+		ExtractorConcurrency:      metadataExtractorConcurrency(configs),
+		ExtractorTimeout:          metadataExtractorTimeout(configs),
+		ExtractedProperties:       metadataExtractorProperties(configs),
+		RequiredProperties:        requiredProperties(configs),
+		PreferredProperties:       preferredProperties(configs),
+		RequiredFilter:            requiredFilter(configs),
+		PreferredFilter:           preferredFilter(configs),
+	}
+	if err := checkExtractedProperties(&config); err != nil {
+		return nil, err
 	}
 	log.Debug("Resolved config: %s", &config)
 	return &config, nil
 }
 
+// checkExtractedProperties fails when the property constraints or the filter expressions use system properties
+// which are not extracted, as they would never be defined.
+func checkExtractedProperties(config *Config) error {
+	uses := []struct {
+		key        string
+		properties []string
+	}{
+		{"jvm.property.required", constraintKeys(config.RequiredProperties)},
+		{"jvm.property.preferred", constraintKeys(config.PreferredProperties)},
+		{"jvm.filter.required", filterProperties(config.RequiredFilter)},
+		{"jvm.filter.preferred", filterProperties(config.PreferredFilter)},
+	}
+	for _, use := range uses {
+		if unextracted := UnextractedProperties(config.ExtractedProperties, use.properties); len(unextracted) > 0 {
+			return fmt.Errorf("invalid configuration: %s uses the system properties %v which are not extracted, "+
+				"add them to metadata.extractor.properties", use.key, unextracted)
+		}
+	}
+	return nil
+}
+
+func constraintKeys(constraints []*PropertyConstraint) []string {
+	var keys []string
+	for _, constraint := range constraints {
+		keys = append(keys, constraint.Key)
+	}
+	return keys
+}
+
+func filterProperties(filter *FilterExpression) []string {
+	if filter == nil {
+		return nil
+	}
+	return filter.Properties()
+}
+
+// MetadataCachePath returns the path of the JVMs metadata cache file in the given cache directory.
+func MetadataCachePath(cacheDir string) string {
+	return filepath.Join(cacheDir, metadataCacheFileName)
+}
+
+// DefaultConfigEntry returns the built-in configuration used when no configuration file defines a value.
+func DefaultConfigEntry() ConfigEntry {
+	return defaultConfigEntry
+}
+
+// LoadConfigFile parses a single configuration file.
+func LoadConfigFile(path string) (ConfigEntry, error) {
+	return loadConfigFromFile(path)
+}
+
 func loadConfigFromFile(path string) (ConfigEntry, error) {
 	log.Debug("Loading config from %s", path)
 	configEntry := ConfigEntry{
@@ -126,6 +275,10 @@ func loadConfigFromFile(path string) (ConfigEntry, error) {
 	if err := scanner.Err(); err != nil {
 		return configEntry, err
 	}
+	if configEntry.JvmVersionRange != nil && configEntry.JvmVersionExpression != nil {
+		return configEntry, fmt.Errorf("invalid configuration in file %s: java.specification.version cannot be "+
+			"combined with java.specification.version.min or java.specification.version.max", path)
+	}
 	return configEntry, err
 }
 
@@ -136,6 +289,69 @@ func processLine(configEntry *ConfigEntry, key string, value string) error {
 			paths = append(paths, strings.TrimSpace(p))
 		}
 		configEntry.JvmLookupPaths = paths
+	} else if key == "jvm.arch.preferred" {
+		archs := []string{}
+		for _, arch := range strings.Split(value, ",") {
+			if arch = strings.TrimSpace(arch); arch == "" {
+				return fmt.Errorf("empty architecture")
+			} else if arch == anyArch {
+				if len(strings.Split(value, ",")) > 1 {
+					return fmt.Errorf("'%s' cannot be combined with other architectures", anyArch)
+				}
+			} else {
+				archs = append(archs, arch)
+			}
+		}
+		configEntry.JvmPreferredArchs = archs
+	} else if key == "metadata.extractor.concurrency" {
+		concurrency, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || concurrency < 0 {
+			return fmt.Errorf("concurrency must be a positive integer or 0 for the number of CPUs")
+		}
+		configEntry.ExtractorConcurrency = &concurrency
+	} else if key == "metadata.extractor.timeout" {
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("timeout must be a positive duration, for example 10s or 1m30s")
+		}
+		configEntry.ExtractorTimeout = &timeout
+	} else if key == "metadata.extractor.properties" {
+		var properties []string
+		for _, property := range strings.Split(value, ",") {
+			if property = strings.TrimSpace(property); property == "" {
+				return fmt.Errorf("empty property")
+			} else if strings.Contains(strings.TrimSuffix(property, "*"), "*") {
+				return fmt.Errorf("invalid property pattern '%s': '*' is only allowed at the end", property)
+			}
+			properties = append(properties, property)
+		}
+		configEntry.ExtractedProperties = properties
+	} else if key == "jvm.property.required" || key == "jvm.property.preferred" {
+		constraint, err := ParsePropertyConstraint(value)
+		if err != nil {
+			return err
+		}
+		if key == "jvm.property.required" {
+			configEntry.RequiredProperties = append(configEntry.RequiredProperties, constraint)
+		} else {
+			configEntry.PreferredProperties = append(configEntry.PreferredProperties, constraint)
+		}
+	} else if key == "jvm.filter.required" || key == "jvm.filter.preferred" {
+		filter, err := ParseFilterExpression(value)
+		if err != nil {
+			return err
+		}
+		if key == "jvm.filter.required" {
+			configEntry.RequiredFilter = filter
+		} else {
+			configEntry.PreferredFilter = filter
+		}
+	} else if key == "java.specification.version" {
+		expression, err := ParseVersionExpression(value)
+		if err != nil {
+			return err
+		}
+		configEntry.JvmVersionExpression = expression
 	} else if key == "java.specification.version.min" {
 		initJvmVersionRange(configEntry)
 		version, err := ParseJavaSpecificationVersion(value)
@@ -184,13 +400,97 @@ func jvmsLookupPaths(configs []ConfigEntry) ([]string, error) {
 	return nil, fmt.Errorf("no JVMs lookup path defined in configuration files %v\n", paths(configs))
 }
 
-func jvmVersionRange(configs []ConfigEntry) (VersionRange, error) {
+func jvmVersionRange(configs []ConfigEntry) (VersionMatcher, error) {
 	for _, cfg := range configs {
-		if cfg.JvmVersionRange != nil {
-			return *cfg.JvmVersionRange, nil
+		if matcher := cfg.versionMatcher(); matcher != nil {
+			return matcher, nil
 		}
 	}
-	return VersionRange{}, fmt.Errorf("no version range defined in configuration files %v\n", paths(configs))
+	return nil, fmt.Errorf("no version range defined in configuration files %v\n", paths(configs))
+}
+
+// jvmPreferredArchs returns the preferred architectures of the first configuration defining them,
+// the host architecture being resolved and the architecture names normalized.
+func jvmPreferredArchs(configs []ConfigEntry) []string {
+	for _, cfg := range configs {
+		if cfg.JvmPreferredArchs != nil {
+			var archs []string
+			for _, arch := range cfg.JvmPreferredArchs {
+				if arch == hostArch {
+					archs = append(archs, HostArch())
+				} else {
+					archs = append(archs, NormalizeArch(arch))
+				}
+			}
+			return archs
+		}
+	}
+	return nil
+}
+
+func metadataExtractorConcurrency(configs []ConfigEntry) int {
+	for _, cfg := range configs {
+		if cfg.ExtractorConcurrency != nil {
+			return *cfg.ExtractorConcurrency
+		}
+	}
+	return 0
+}
+
+func metadataExtractorTimeout(configs []ConfigEntry) time.Duration {
+	for _, cfg := range configs {
+		if cfg.ExtractorTimeout != nil {
+			return *cfg.ExtractorTimeout
+		}
+	}
+	return 0
+}
+
+func metadataExtractorProperties(configs []ConfigEntry) []string {
+	for _, cfg := range configs {
+		if cfg.ExtractedProperties != nil {
+			return cfg.ExtractedProperties
+		}
+	}
+	return nil
+}
+
+// requiredProperties returns the required property constraints of the first configuration defining some.
+func requiredProperties(configs []ConfigEntry) []*PropertyConstraint {
+	for _, cfg := range configs {
+		if cfg.RequiredProperties != nil {
+			return cfg.RequiredProperties
+		}
+	}
+	return nil
+}
+
+// preferredProperties returns the preferred property constraints of the first configuration defining some.
+func preferredProperties(configs []ConfigEntry) []*PropertyConstraint {
+	for _, cfg := range configs {
+		if cfg.PreferredProperties != nil {
+			return cfg.PreferredProperties
+		}
+	}
+	return nil
+}
+
+func requiredFilter(configs []ConfigEntry) *FilterExpression {
+	for _, cfg := range configs {
+		if cfg.RequiredFilter != nil {
+			return cfg.RequiredFilter
+		}
+	}
+	return nil
+}
+
+func preferredFilter(configs []ConfigEntry) *FilterExpression {
+	for _, cfg := range configs {
+		if cfg.PreferredFilter != nil {
+			return cfg.PreferredFilter
+		}
+	}
+	return nil
 }
 
 func paths(configs []ConfigEntry) []string {
@@ -1,11 +1,13 @@
 package config
 
 import (
+	"errors"
 	. "findjava/internal/jvm"
 	"findjava/internal/utils"
 	"findjava/test"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestLoadInvalidConfig(t *testing.T) {
@@ -18,6 +20,46 @@ func TestLoadInvalidConfig(t *testing.T) {
 			"invalid configuration entry in file test-resources/invalid-min-java-version.conf for key 'java.specification.version.min' and value '-1'",
 			"JVM version '-1' cannot be parsed as an unsigned int",
 		},
+		"test-resources/invalid-version-expression.conf": {
+			"invalid configuration entry in file test-resources/invalid-version-expression.conf for key 'java.specification.version' and value '[11,17'",
+			"invalid version expression \"[11,17\": invalid term \"[11,17\": range must end with ']' or ')'",
+		},
+		"test-resources/invalid-combined-version.conf": {
+			"invalid configuration in file test-resources/invalid-combined-version.conf: java.specification.version cannot be " +
+				"combined with java.specification.version.min or java.specification.version.max",
+		},
+		"test-resources/invalid-preferred-arch.conf": {
+			"invalid configuration entry in file test-resources/invalid-preferred-arch.conf for key 'jvm.arch.preferred' and value 'any, aarch64'",
+			"'any' cannot be combined with other architectures",
+		},
+		"test-resources/invalid-extractor-concurrency.conf": {
+			"invalid configuration entry in file test-resources/invalid-extractor-concurrency.conf for key 'metadata.extractor.concurrency' and value '-1'",
+			"concurrency must be a positive integer or 0 for the number of CPUs",
+		},
+		"test-resources/invalid-extractor-timeout.conf": {
+			"invalid configuration entry in file test-resources/invalid-extractor-timeout.conf for key 'metadata.extractor.timeout' and value '10'",
+			"timeout must be a positive duration, for example 10s or 1m30s",
+		},
+		"test-resources/invalid-extractor-properties.conf": {
+			"invalid configuration entry in file test-resources/invalid-extractor-properties.conf for key 'metadata.extractor.properties' and value 'java.*.version'",
+			"invalid property pattern 'java.*.version': '*' is only allowed at the end",
+		},
+		"test-resources/invalid-property-constraint.conf": {
+			"invalid configuration entry in file test-resources/invalid-property-constraint.conf for key 'jvm.property.required' and value 'java.vm.name~=(OpenJ9'",
+			"invalid property constraint \"java.vm.name~=(OpenJ9\": error parsing regexp: missing closing ): `(OpenJ9`",
+		},
+		"test-resources/invalid-filter.conf": {
+			"invalid configuration entry in file test-resources/invalid-filter.conf for key 'jvm.filter.required' and value 'spec >= 17 && vendor in [\"Eclipse Adoptium\"'",
+			"invalid filter expression: unexpected end of expression, expecting \",\" at column 44",
+		},
+		"test-resources/invalid-unextracted-constraint.conf": {
+			"invalid configuration: jvm.property.preferred uses the system properties [jdk.debug] which are not extracted, " +
+				"add them to metadata.extractor.properties",
+		},
+		"test-resources/invalid-unextracted-property.conf": {
+			"invalid configuration: jvm.filter.required uses the system properties [jdk.debug] which are not extracted, " +
+				"add them to metadata.extractor.properties",
+		},
 		"test-resources/invalid-max-java-version.conf": {
 			"invalid configuration entry in file test-resources/invalid-max-java-version.conf for key 'java.specification.version.max' and value 'this is obviously invalid'",
 			"JVM version 'this is obviously invalid' cannot be parsed as an unsigned int",
@@ -29,6 +71,10 @@ func TestLoadInvalidConfig(t *testing.T) {
 		for _, e := range expected {
 			test.AssertErrorContains(t, description, e, err)
 		}
+		var configError *ConfigError
+		if !errors.As(err, &configError) {
+			t.Fatalf("Expecting %s to fail with a ConfigError but got %#v", description, err)
+		}
 	}
 }
 
@@ -87,7 +133,7 @@ func TestLoadConfig(t *testing.T) {
 		description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, defaultKey)
 		test.AssertNoError(t, description, err)
 		test.AssertEquals(t, description+".jvmLookupPaths()", expected.JvmLookupPaths, actual.JvmsLookupPaths)
-		test.AssertEquals(t, description+".JvmVersionRange()", *expected.JvmVersionRange, actual.JvmVersionRange)
+		test.AssertEquals(t, description+".JvmVersionRange()", expected.JvmVersionRange, actual.JvmVersionRange)
 	}
 }
 
@@ -119,6 +165,112 @@ func TestLoadConfigWithOverrides(t *testing.T) {
 		description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, key)
 		test.AssertNoError(t, description, err)
 		test.AssertEquals(t, description+".jvmLookupPaths()", expected.JvmLookupPaths, actual.JvmsLookupPaths)
-		test.AssertEquals(t, description+".JvmVersionRange()", *expected.JvmVersionRange, actual.JvmVersionRange)
+		test.AssertEquals(t, description+".JvmVersionRange()", expected.JvmVersionRange, actual.JvmVersionRange)
+	}
+}
+
+func TestLoadConfigWithVersionExpression(t *testing.T) {
+	path := "test-resources/version-expression.conf"
+	actual, err := loadConfig(path, defaultKey, "", "")
+	description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, defaultKey)
+	test.AssertNoError(t, description, err)
+	expected, _ := ParseVersionExpression("11|17")
+	test.AssertEquals(t, description+".JvmVersionRange()", expected, actual.JvmVersionRange)
+}
+
+func TestLoadConfigWithPreferredArchs(t *testing.T) {
+	data := map[string][]string{
+		"test-resources/empty.conf":          {HostArch()},
+		"test-resources/preferred-arch.conf": {"amd64", HostArch()},
+		"test-resources/any-arch.conf":       nil,
+	}
+	for path, expected := range data {
+		actual, err := loadConfig(path, defaultKey, "", "")
+		description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, defaultKey)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".JvmPreferredArchs", expected, actual.JvmPreferredArchs)
+	}
+}
+
+func TestLoadConfigWithExtractorConcurrency(t *testing.T) {
+	data := map[string]int{
+		"test-resources/empty.conf":                 0,
+		"test-resources/extractor-concurrency.conf": 2,
+	}
+	for path, expected := range data {
+		actual, err := loadConfig(path, defaultKey, "", "")
+		description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, defaultKey)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".ExtractorConcurrency", expected, actual.ExtractorConcurrency)
+	}
+}
+
+func TestLoadConfigWithExtractorTimeout(t *testing.T) {
+	data := map[string]time.Duration{
+		"test-resources/empty.conf":             0,
+		"test-resources/extractor-timeout.conf": 30 * time.Second,
+	}
+	for path, expected := range data {
+		actual, err := loadConfig(path, defaultKey, "", "")
+		description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, defaultKey)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".ExtractorTimeout", expected, actual.ExtractorTimeout)
+	}
+}
+
+func TestLoadConfigWithExtractedProperties(t *testing.T) {
+	data := map[string][]string{
+		"test-resources/empty.conf": {"java.*", "os.arch", "sun.arch.data.model"},
+		"test-resources/extractor-properties.conf": {
+			"java.*", "os.arch", "sun.arch.data.model", "jdk.debug", "java.vm.*",
+		},
+	}
+	for path, expected := range data {
+		actual, err := loadConfig(path, defaultKey, "", "")
+		description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, defaultKey)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".ExtractedProperties", expected, actual.ExtractedProperties)
+	}
+}
+
+func TestLoadConfigWithPropertyConstraints(t *testing.T) {
+	data := map[string][2][]string{
+		"test-resources/empty.conf": {nil, nil},
+		"test-resources/property-constraints.conf": {
+			{"java.vm.name~=OpenJ9", "java.vendor!=Oracle Corporation"},
+			{"jdk.debug=release"},
+		},
+	}
+	for path, expected := range data {
+		actual, err := loadConfig(path, defaultKey, "", "")
+		description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, defaultKey)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".RequiredProperties", expected[0], constraintsToStrings(actual.RequiredProperties))
+		test.AssertEquals(t, description+".PreferredProperties", expected[1], constraintsToStrings(actual.PreferredProperties))
+	}
+}
+
+func TestLoadConfigWithFilters(t *testing.T) {
+	data := map[string][2]string{
+		"test-resources/empty.conf": {"<nil>", "<nil>"},
+		"test-resources/filters.conf": {
+			`!has("jdk.debug") || prop("jdk.debug") == "release"`,
+			`vendor in ["Eclipse Adoptium", "Azul Systems, Inc."]`,
+		},
+	}
+	for path, expected := range data {
+		actual, err := loadConfig(path, defaultKey, "", "")
+		description := fmt.Sprintf("loadConfig(\"%s\", \"%s\")", path, defaultKey)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".RequiredFilter", expected[0], fmt.Sprintf("%v", actual.RequiredFilter))
+		test.AssertEquals(t, description+".PreferredFilter", expected[1], fmt.Sprintf("%v", actual.PreferredFilter))
+	}
+}
+
+func constraintsToStrings(constraints []*PropertyConstraint) []string {
+	var result []string
+	for _, constraint := range constraints {
+		result = append(result, constraint.String())
 	}
+	return result
 }
@@ -0,0 +1,27 @@
+package config
+
+// PlatformError is returned when the findjava location or its directories cannot be resolved.
+type PlatformError struct {
+	Err error
+}
+
+func (e *PlatformError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PlatformError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError is returned when the configuration files cannot be read or are invalid.
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
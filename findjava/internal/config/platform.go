@@ -13,6 +13,8 @@ type Platform struct {
 	ConfigDir            string
 	CacheDir             string
 	MetadataExtractorDir string
+	// SystemCacheDir is the directory of the system-wide cache, empty when there is none.
+	SystemCacheDir string
 }
 
 func (p *Platform) String() string {
@@ -20,7 +22,8 @@ func (p *Platform) String() string {
 	program:                        %s
 	config directory:               %s
 	cache directory:                %s
-	metadata extractor directory:   %s`, p.SelfPath, p.ConfigDir, p.CacheDir, p.MetadataExtractorDir)
+	system cache directory:         %s
+	metadata extractor directory:   %s`, p.SelfPath, p.ConfigDir, p.CacheDir, p.SystemCacheDir, p.MetadataExtractorDir)
 }
 
 func (p *Platform) LoadConfig(key string) (*Config, error) {
@@ -28,10 +31,34 @@ func (p *Platform) LoadConfig(key string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return loadConfig(filepath.Join(p.ConfigDir, "config.conf"), key, p.CacheDir, p.MetadataExtractorDir)
+	cfg, err := loadConfig(p.defaultConfigPath(), key, p.CacheDir, p.MetadataExtractorDir)
+	if err != nil {
+		return nil, err
+	}
+	if p.SystemCacheDir != "" {
+		cfg.JvmsSystemMetadataCachePath = MetadataCachePath(p.SystemCacheDir)
+	}
+	return cfg, nil
+}
+
+// ConfigPaths returns the paths of the configuration files which would be loaded for the given key,
+// by order of precedence. Those files are optional and might not exist.
+func (p *Platform) ConfigPaths(key string) []string {
+	return configPaths(key, p.defaultConfigPath())
+}
+
+func (p *Platform) defaultConfigPath() string {
+	return filepath.Join(p.ConfigDir, "config.conf")
 }
 
 func (p *Platform) Resolve() error {
+	if err := p.resolve(); err != nil {
+		return &PlatformError{Err: err}
+	}
+	return nil
+}
+
+func (p *Platform) resolve() error {
 	self, err := os.Executable()
 	if err != nil {
 		return log.WrapErr(err, "unable to resolve findjava self location")
@@ -58,6 +85,12 @@ func (p *Platform) Resolve() error {
 	if err != nil {
 		return err
 	}
+	if p.SystemCacheDir != "" {
+		p.SystemCacheDir, err = toAbsolutePath(selfDir, p.SystemCacheDir)
+		if err != nil {
+			return err
+		}
+	}
 	log.Debug("%v", p)
 	return nil
 }
@@ -65,7 +98,7 @@ func (p *Platform) Resolve() error {
 func toAbsolutePath(self string, path string) (string, error) {
 	path, err := utils.ResolvePath(path)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	if filepath.IsAbs(path) {
 		return path, nil
@@ -1,37 +1,243 @@
 package jvm
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"findjava/internal/log"
+	"fmt"
+	"os"
 	"os/exec"
+	"runtime"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
+// MetadataExtractorClass is the name of the java class extracting the JVM metadata.
+const MetadataExtractorClass = "JvmMetadataExtractor"
+
+// ExtractorError is returned when the metadata of a JVM cannot be extracted.
+type ExtractorError struct {
+	JavaPath string
+	Err      error
+}
+
+func (e *ExtractorError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExtractorError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError is returned when a JVM launched to fetch its metadata is killed on timeout. The next metadata
+// strategies are not tried as they would launch the same hanging JVM.
+type TimeoutError struct {
+	JavaPath string
+	Args     []string
+	Timeout  time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s with args [%s] did not complete within %s and has been killed", e.JavaPath,
+		strings.Join(e.Args, ", "), e.Timeout)
+}
+
+// ExtractorErrors is returned when the metadata of several JVMs cannot be extracted.
+// It unwraps to the first error so that it can be handled as an ExtractorError.
+type ExtractorErrors struct {
+	Errors []error
+}
+
+func (e *ExtractorErrors) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("unable to extract the metadata of %d JVMs:\n  - %s", len(e.Errors),
+		strings.Join(messages, "\n  - "))
+}
+
+func (e *ExtractorErrors) Unwrap() error {
+	return e.Errors[0]
+}
+
+// newExtractorErrors returns nil without errors, the error itself for a single error
+// and an ExtractorErrors otherwise.
+func newExtractorErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return &ExtractorErrors{Errors: errs}
+	}
+}
+
+// DefaultExtractorTimeout is the time after which a JVM launched to extract its metadata is killed.
+const DefaultExtractorTimeout = 10 * time.Second
+
+// scrubbedEnvVars are the environment variables removed when launching a JVM to extract its metadata,
+// as they can alter its behavior and output.
+var scrubbedEnvVars = []string{"JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS", "JDK_JAVA_OPTIONS"}
+
+// startupOptions minimize the startup time of the JVMs launched to extract their metadata.
+// Options unknown to a JVM are ignored thanks to -XX:+IgnoreUnrecognizedVMOptions.
+var startupOptions = []string{
+	"-XX:+IgnoreUnrecognizedVMOptions",
+	"-XX:TieredStopAtLevel=1",
+	"-XX:+UseSerialGC",
+	"-XX:-UsePerfData",
+	"-Xshare:auto",
+}
+
 type MetadataReader struct {
+	// Classpath is the directory of a metadata extractor class overriding the embedded one.
 	Classpath string
+	// CacheDir is the directory in which the embedded metadata extractor class is materialized.
+	CacheDir string
+	// Properties are the patterns of the system properties to extract, DefaultExtractedProperties when empty.
+	Properties []string
+	// ReferencedProperties are the system properties read by the JVM selection rules, "*" standing for any
+	// property. The JVMs are launched when the release files do not provide them.
+	ReferencedProperties []string
+	// Version is the version of findjava, recorded in the cache to discard it on upgrades.
+	Version string
+	// Concurrency is the maximum number of JVMs whose metadata are fetched at the same time,
+	// zero meaning the number of CPUs.
+	Concurrency int
+	// Timeout is the time after which a JVM launched to extract its metadata is killed,
+	// zero meaning DefaultExtractorTimeout.
+	Timeout time.Duration
+
+	extractorOnce sync.Once
+	extractorDir  string
+	extractorErr  error
+}
+
+// cacheHeader returns the header of the cache entries produced by this reader.
+func (f *MetadataReader) cacheHeader() CacheHeader {
+	return CacheHeader{
+		SchemaVersion:   cacheSchemaVersion,
+		FindjavaVersion: f.Version,
+		ExtractorHash:   f.extractorHash(),
+		Properties:      strings.Join(f.extractedProperties(), ","),
+	}
+}
+
+func (f *MetadataReader) timeout() time.Duration {
+	if f.Timeout <= 0 {
+		return DefaultExtractorTimeout
+	}
+	return f.Timeout
 }
 
+// concurrency returns the number of workers to use to fetch the metadata of the given number of JVMs.
+func (f *MetadataReader) concurrency(jvmsCount int) int {
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	if concurrency > jvmsCount {
+		concurrency = jvmsCount
+	}
+	return concurrency
+}
+
+// fetchJvmInfo fetches the JVM metadata with the first metadata strategy which succeeds.
 func (f *MetadataReader) fetchJvmInfo(javaPath string) (*Jvm, error) {
-	cmd := exec.Command(javaPath, "-cp", f.Classpath, "JvmMetadataExtractor")
-	output, err := cmd.CombinedOutput()
+	var failures []string
+	for _, strategy := range metadataStrategies {
+		jvm, err := strategy.fetch(f, javaPath)
+		if err != nil {
+			log.Debug("Unable to fetch the metadata of %s with the %s strategy: %v", javaPath, strategy.name, err)
+			failures = append(failures, fmt.Sprintf("%s: %s", strategy.name,
+				strings.ReplaceAll(err.Error(), "\n\t", ": ")))
+			var timeout *TimeoutError
+			if errors.As(err, &timeout) {
+				// The next strategies would launch the same hanging JVM
+				return nil, &ExtractorError{
+					JavaPath: javaPath,
+					Err: fmt.Errorf("unable to fetch the metadata of %s with the %s strategy: %w", javaPath,
+						strategy.name, err),
+				}
+			}
+			continue
+		}
+		log.Debug("Metadata of %s fetched with the %s strategy", javaPath, strategy.name)
+		jvm.Strategy = strategy.name
+		jvm.Fingerprint = computeFingerprint(javaPath, jvm.JavaHome)
+		return jvm, nil
+	}
+	return nil, &ExtractorError{
+		JavaPath: javaPath,
+		Err: fmt.Errorf("unable to fetch the metadata of %s with any strategy: %s", javaPath,
+			strings.Join(failures, "; ")),
+	}
+}
+
+// run launches the java executable with a scrubbed environment and returns its standard output and error.
+// The process and its children are killed if it does not complete before the timeout, its standard error being
+// used to describe failures.
+func (f *MetadataReader) run(javaPath string, args ...string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout())
+	defer cancel()
+	cmd := exec.Command(javaPath, args...)
+	cmd.Env = scrubbedEnvironment(os.Environ())
+	// The JVM runs in its own process group so that the processes it may have spawned are killed with it,
+	// otherwise they would keep its output open and block until they complete
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return "", "", log.WrapErr(err, "fail to call %s with args [%s]", javaPath, strings.Join(args, ", "))
+	}
+	done := make(chan struct{})
+	killed := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			killed <- true
+		case <-done:
+			killed <- false
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+	if <-killed {
+		return "", "", &TimeoutError{JavaPath: javaPath, Args: args, Timeout: f.timeout()}
+	}
 	if err != nil {
-		return nil, log.WrapErr(err, "fail to call %s with args [%s]", javaPath, strings.Join(cmd.Args[1:], ", "))
-	}
-	lines := strings.Split(string(output), "\n")
-	systemProperties := make(map[string]string)
-	for _, line := range lines {
-		split := strings.SplitN(line, "=", 2)
-		if len(split) == 2 {
-			systemProperties[split[0]] = strings.TrimSpace(split[1])
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
 		}
+		return "", "", log.WrapErr(err, "fail to call %s with args [%s]", javaPath, strings.Join(args, ", "))
 	}
-	jvmInfo := Jvm{
-		javaPath:         javaPath,
-		FetchedAt:        time.Now(),
-		SystemProperties: systemProperties,
+	return stdout.String(), stderr.String(), nil
+}
+
+// scrubbedEnvironment returns the environment without the variables altering the JVMs behavior.
+func scrubbedEnvironment(environment []string) []string {
+	var scrubbed []string
+	for _, envVar := range environment {
+		name := strings.SplitN(envVar, "=", 2)[0]
+		if !isScrubbed(name) {
+			scrubbed = append(scrubbed, envVar)
+		}
 	}
-	if err := jvmInfo.rebuild(); err != nil {
-		return nil, err
+	return scrubbed
+}
+
+func isScrubbed(name string) bool {
+	for _, scrubbed := range scrubbedEnvVars {
+		if name == scrubbed {
+			return true
+		}
 	}
-	return &jvmInfo, nil
+	return false
 }
@@ -0,0 +1,66 @@
+package jvm
+
+import (
+	"errors"
+	"findjava/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunReturnsStderr(t *testing.T) {
+	reader := &MetadataReader{}
+	output, stderr, err := reader.run("/bin/sh", "-c", "echo java.home=/jvm; echo 'Picked up JAVA_TOOL_OPTIONS: -Xmx1g' >&2")
+	test.AssertNoError(t, "run()", err)
+	test.AssertEquals(t, "run()", "java.home=/jvm\n", output)
+	test.AssertEquals(t, "run() stderr", "Picked up JAVA_TOOL_OPTIONS: -Xmx1g\n", stderr)
+}
+
+func TestRunReportsStderrOnFailure(t *testing.T) {
+	reader := &MetadataReader{}
+	_, _, err := reader.run("/bin/sh", "-c", "echo 'Error: Could not find or load main class' >&2; exit 1")
+	test.AssertErrorContains(t, "run()", "exit status 1: Error: Could not find or load main class", err)
+}
+
+func TestRunScrubsEnvironment(t *testing.T) {
+	for _, envVar := range scrubbedEnvVars {
+		_ = os.Setenv(envVar, "-Xmx1g")
+		defer func(envVar string) { _ = os.Unsetenv(envVar) }(envVar)
+	}
+	reader := &MetadataReader{}
+	output, _, err := reader.run("/bin/sh", "-c", "echo \"[$JAVA_TOOL_OPTIONS$_JAVA_OPTIONS$JDK_JAVA_OPTIONS]\"")
+	test.AssertNoError(t, "run()", err)
+	test.AssertEquals(t, "run()", "[]\n", output)
+}
+
+func TestRunTimeout(t *testing.T) {
+	reader := &MetadataReader{Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	// The child process keeps the output open, it must be killed with the shell
+	_, _, err := reader.run("/bin/sh", "-c", "sleep 10 & sleep 10")
+	test.AssertErrorContains(t, "run()", "did not complete within 100ms and has been killed", err)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Expecting run() to be killed after 100ms but it took %s", elapsed)
+	}
+}
+
+func TestFetchJvmInfoStopsOnTimeout(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	javaPath := filepath.Join(directory, "bin", "java")
+	test.AssertNoError(t, "os.MkdirAll()", os.MkdirAll(filepath.Dir(javaPath), 0755))
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(javaPath, []byte("#!/bin/sh\nsleep 10\n"), 0755))
+	reader := &MetadataReader{CacheDir: directory, Timeout: 500 * time.Millisecond}
+	start := time.Now()
+	_, err = reader.fetchJvmInfo(javaPath)
+	var timeout *TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("Expecting fetchJvmInfo() to fail with a TimeoutError but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*reader.Timeout {
+		t.Fatalf("Expecting fetchJvmInfo() to stop after a single timeout of %s but it took %s", reader.Timeout, elapsed)
+	}
+}
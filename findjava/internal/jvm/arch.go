@@ -0,0 +1,47 @@
+package jvm
+
+import (
+	"runtime"
+	"strings"
+)
+
+// archAliases maps the architecture names reported by the JVMs (os.arch) and by Go (GOARCH)
+// to the name used by findjava, which is the one reported by the Linux JVMs.
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x64":     "amd64",
+	"arm64":   "aarch64",
+	"386":     "x86",
+	"i386":    "x86",
+	"i486":    "x86",
+	"i586":    "x86",
+	"i686":    "x86",
+	"ppc64el": "ppc64le",
+}
+
+// NormalizeArch returns the canonical name of a CPU architecture so that, for example,
+// x86_64 and amd64 or arm64 and aarch64 are considered the same architecture.
+func NormalizeArch(arch string) string {
+	arch = strings.ToLower(strings.TrimSpace(arch))
+	if alias, found := archAliases[arch]; found {
+		return alias
+	}
+	return arch
+}
+
+// HostArch returns the canonical name of the CPU architecture findjava is running on.
+func HostArch() string {
+	return NormalizeArch(runtime.GOARCH)
+}
+
+// archDataModel returns the data model of the JVMs running on the given canonical architecture,
+// or zero when it is not known.
+func archDataModel(arch string) uint {
+	switch arch {
+	case "amd64", "aarch64", "ppc64", "ppc64le", "s390x", "riscv64", "sparcv9", "loongarch64":
+		return 64
+	case "x86", "arm", "ppc", "s390":
+		return 32
+	}
+	return 0
+}
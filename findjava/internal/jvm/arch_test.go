@@ -0,0 +1,26 @@
+package jvm
+
+import (
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeArch(t *testing.T) {
+	archs := map[string]string{
+		"amd64":   "amd64",
+		"x86_64":  "amd64",
+		"X86_64":  "amd64",
+		"aarch64": "aarch64",
+		"arm64":   "aarch64",
+		"i386":    "x86",
+		"386":     "x86",
+		"x86":     "x86",
+		"ppc64le": "ppc64le",
+		"s390x":   "s390x",
+		"":        "",
+	}
+	for arch, expected := range archs {
+		test.AssertEquals(t, fmt.Sprintf("NormalizeArch(%s)", arch), expected, NormalizeArch(arch))
+	}
+}
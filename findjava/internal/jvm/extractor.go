@@ -0,0 +1,110 @@
+package jvm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"findjava/internal/log"
+	"findjava/internal/utils"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// extractorClassFile is the name of the file of the metadata extractor class.
+const extractorClassFile = MetadataExtractorClass + ".class"
+
+// embeddedExtractor holds the metadata extractor class copied to the metadata-extractor directory by the build.
+// It only contains a .gitignore file when findjava was built without it, with go build for example.
+//
+//go:embed metadata-extractor/*
+var embeddedExtractor embed.FS
+
+// embeddedExtractorClass returns the embedded metadata extractor class, or nil if findjava was built without it.
+func embeddedExtractorClass() []byte {
+	content, err := embeddedExtractor.ReadFile("metadata-extractor/" + extractorClassFile)
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// EmbeddedExtractorHash returns the SHA-256 of the embedded metadata extractor class,
+// or an empty string if findjava was built without it.
+func EmbeddedExtractorHash() string {
+	if content := embeddedExtractorClass(); content != nil {
+		return contentHash(content)
+	}
+	return ""
+}
+
+func contentHash(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// overrideExtractorClass returns the metadata extractor class of the Classpath directory, which overrides the embedded
+// one, or nil if it does not exist.
+func (f *MetadataReader) overrideExtractorClass() []byte {
+	if f.Classpath == "" {
+		return nil
+	}
+	content, err := ioutil.ReadFile(filepath.Join(f.Classpath, extractorClassFile))
+	if err != nil {
+		log.Debug("No metadata extractor override in %s: %v", f.Classpath, err)
+		return nil
+	}
+	return content
+}
+
+// extractorHash returns the SHA-256 of the metadata extractor class in use, or an empty string if there is none.
+func (f *MetadataReader) extractorHash() string {
+	if content := f.overrideExtractorClass(); content != nil {
+		return contentHash(content)
+	}
+	return EmbeddedExtractorHash()
+}
+
+// extractorClasspath returns the directory holding the metadata extractor class in use, materializing the embedded
+// class on first use. It is resolved once and shared by the concurrent extractions.
+func (f *MetadataReader) extractorClasspath() (string, error) {
+	f.extractorOnce.Do(func() {
+		f.extractorDir, f.extractorErr = f.resolveExtractorClasspath()
+	})
+	return f.extractorDir, f.extractorErr
+}
+
+func (f *MetadataReader) resolveExtractorClasspath() (string, error) {
+	if f.overrideExtractorClass() != nil {
+		log.Debug("Using the metadata extractor override of %s", f.Classpath)
+		return f.Classpath, nil
+	}
+	content := embeddedExtractorClass()
+	if content == nil {
+		return "", fmt.Errorf("metadata extractor %s not found in %s and not embedded in findjava",
+			extractorClassFile, f.Classpath)
+	}
+	cacheDir := f.CacheDir
+	if cacheDir == "" {
+		cacheDir = os.TempDir()
+	}
+	return materializeExtractor(cacheDir, content)
+}
+
+// materializeExtractor writes the metadata extractor class to a directory of the cache directory named after its
+// SHA-256, unless it already exists, and returns that directory.
+func materializeExtractor(cacheDir string, content []byte) (string, error) {
+	directory := filepath.Join(cacheDir, "metadata-extractor", contentHash(content))
+	path := filepath.Join(directory, extractorClassFile)
+	if existing, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existing, content) {
+		return directory, nil
+	}
+	log.Debug("Materializing the embedded metadata extractor to %s", path)
+	if err := utils.CreateDirectory(directory); err != nil {
+		return "", log.WrapErr(err, "unable to create directory %s for the metadata extractor", directory)
+	}
+	if err := utils.WriteFileAtomic(path, content, 0644); err != nil {
+		return "", log.WrapErr(err, "unable to write the metadata extractor to %s", path)
+	}
+	return directory, nil
+}
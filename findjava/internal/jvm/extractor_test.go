@@ -0,0 +1,43 @@
+package jvm
+
+import (
+	"findjava/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMaterializeExtractor(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	content := []byte("extractor class")
+	expected := filepath.Join(directory, "metadata-extractor", contentHash(content))
+	classPath := filepath.Join(expected, extractorClassFile)
+	for _, description := range []string{"materialize", "reuse", "repair"} {
+		if description == "repair" {
+			test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(classPath, []byte("truncated"), 0644))
+		}
+		actual, err := materializeExtractor(directory, content)
+		test.AssertNoError(t, "materializeExtractor() "+description, err)
+		test.AssertEquals(t, "materializeExtractor() "+description, expected, actual)
+		written, err := ioutil.ReadFile(classPath)
+		test.AssertNoError(t, "ioutil.ReadFile()", err)
+		test.AssertEquals(t, "materialized class "+description, string(content), string(written))
+	}
+}
+
+func TestExtractorClasspathOverride(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	content := []byte("override class")
+	test.AssertNoError(t, "ioutil.WriteFile()",
+		ioutil.WriteFile(filepath.Join(directory, extractorClassFile), content, 0644))
+	reader := &MetadataReader{Classpath: directory}
+	classpath, err := reader.extractorClasspath()
+	test.AssertNoError(t, "extractorClasspath()", err)
+	test.AssertEquals(t, "extractorClasspath()", directory, classpath)
+	test.AssertEquals(t, "extractorHash()", contentHash(content), reader.extractorHash())
+}
@@ -0,0 +1,690 @@
+package jvm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FilterExpression is a condition on the JVMs parsed from an expression such as
+// `spec >= 17 && vendor in ["Eclipse Adoptium", "Azul Systems, Inc."] && !(prop("java.vm.name") ~= "Zero")`.
+//
+// The expression is compiled once by ParseFilterExpression and evaluated against each JVM. It is made of:
+//   - the fields spec (number), version (Java version), vendor, arch, home (strings) and dataModel (number)
+//   - the functions has("key"), true if the system property is defined, and prop("key"), the value of the system
+//     property or "" if it is not defined
+//   - number, string ("..." in which \" and \\ are escaped) and boolean (true, false) literals
+//   - the comparisons ==, !=, <, <=, >, >= (numbers and versions), ~= (the string contains a match of the regular
+//     expression literal) and in [literal, ...]
+//   - the boolean operators !, && and || and parentheses
+//
+// Version fields are compared with string literals parsed as Java versions, such as "17.0.9", or with numbers
+// standing for feature versions. Architecture literals are normalized, "x86_64" being equal to "amd64".
+type FilterExpression struct {
+	expression string
+	// terms are the operands of the top-level && operator, the whole expression being a single term otherwise.
+	terms []filterTerm
+	// properties are the system properties passed to has() and prop(), "*" standing for a computed key.
+	properties []string
+}
+
+type filterTerm struct {
+	source  string
+	matches func(jvm *Jvm) bool
+}
+
+// Matches returns true if the JVM satisfies the expression.
+func (expression *FilterExpression) Matches(jvm *Jvm) bool {
+	for _, term := range expression.terms {
+		if !term.matches(jvm) {
+			return false
+		}
+	}
+	return true
+}
+
+// Mismatches returns the source of every top-level term of the expression the JVM does not satisfy.
+func (expression *FilterExpression) Mismatches(jvm *Jvm) []string {
+	var mismatches []string
+	for _, term := range expression.terms {
+		if !term.matches(jvm) {
+			mismatches = append(mismatches, term.source)
+		}
+	}
+	return mismatches
+}
+
+// Properties returns the sorted system properties the expression reads, "*" meaning that a property key is
+// computed when the expression is evaluated.
+func (expression *FilterExpression) Properties() []string {
+	return expression.properties
+}
+
+func (expression *FilterExpression) String() string {
+	return expression.expression
+}
+
+// FilterExpressionError describes an invalid filter expression and the position of the offending token.
+type FilterExpressionError struct {
+	Expression string
+	// Offset is the byte offset of the offending token in the expression.
+	Offset  int
+	Message string
+}
+
+func (e *FilterExpressionError) Error() string {
+	column := utf8.RuneCountInString(e.Expression[:e.Offset]) + 1
+	return fmt.Sprintf("invalid filter expression: %s at column %d\n\t%s\n\t%s^", e.Message, column,
+		e.Expression, strings.Repeat(" ", column-1))
+}
+
+// ParseFilterExpression compiles a filter expression. See FilterExpression for the syntax.
+func ParseFilterExpression(expression string) (*FilterExpression, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, &FilterExpressionError{Expression: expression, Message: "expression is empty"}
+	}
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	parser := &filterParser{expression: expression, tokens: tokens}
+	root, terms, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != filterEnd {
+		return nil, parser.error(token, "unexpected %s", token)
+	}
+	if root.typ != filterBoolean {
+		return nil, parser.error(root.token, "expecting a boolean expression but got a %s", root.typ)
+	}
+	sort.Strings(parser.properties)
+	compiled := &FilterExpression{expression: expression, properties: parser.properties}
+	for _, term := range terms {
+		matches := term.eval
+		compiled.terms = append(compiled.terms, filterTerm{
+			source:  expression[term.start:term.end],
+			matches: func(jvm *Jvm) bool { return matches(jvm).(bool) },
+		})
+	}
+	return compiled, nil
+}
+
+type filterTokenKind int
+
+const (
+	filterEnd filterTokenKind = iota
+	filterIdentifier
+	filterNumberLiteral
+	filterStringLiteral
+	filterOperator
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	// text is the token as written in the expression.
+	text string
+	// value is the unescaped value of string literals.
+	value  string
+	offset int
+}
+
+func (token filterToken) String() string {
+	switch token.kind {
+	case filterEnd:
+		return "end of expression"
+	case filterIdentifier:
+		return fmt.Sprintf("identifier %s", token.text)
+	case filterNumberLiteral:
+		return fmt.Sprintf("number %s", token.text)
+	case filterStringLiteral:
+		return fmt.Sprintf("string %s", token.text)
+	default:
+		return fmt.Sprintf("\"%s\"", token.text)
+	}
+}
+
+var filterOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "~=", "!", "<", ">", "(", ")", "[", "]", ","}
+
+func tokenizeFilter(expression string) ([]filterToken, error) {
+	var tokens []filterToken
+	offset := 0
+	for offset < len(expression) {
+		c, size := utf8.DecodeRuneInString(expression[offset:])
+		switch {
+		case unicode.IsSpace(c):
+			offset += size
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			end := offset
+			for end < len(expression) && isFilterIdentifierChar(rune(expression[end])) {
+				end++
+			}
+			tokens = append(tokens, filterToken{kind: filterIdentifier, text: expression[offset:end], offset: offset})
+			offset = end
+		case c >= '0' && c <= '9':
+			end := offset
+			for end < len(expression) && (expression[end] == '.' || expression[end] >= '0' && expression[end] <= '9') {
+				end++
+			}
+			tokens = append(tokens, filterToken{kind: filterNumberLiteral, text: expression[offset:end], offset: offset})
+			offset = end
+		case c == '"':
+			token, err := tokenizeFilterString(expression, offset)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			offset += len(token.text)
+		default:
+			operator := ""
+			for _, candidate := range filterOperators {
+				if strings.HasPrefix(expression[offset:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, &FilterExpressionError{Expression: expression, Offset: offset,
+					Message: fmt.Sprintf("unexpected character '%c'", c)}
+			}
+			tokens = append(tokens, filterToken{kind: filterOperator, text: operator, offset: offset})
+			offset += len(operator)
+		}
+	}
+	return append(tokens, filterToken{kind: filterEnd, offset: len(expression)}), nil
+}
+
+func isFilterIdentifierChar(c rune) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func tokenizeFilterString(expression string, offset int) (filterToken, error) {
+	var value strings.Builder
+	for i := offset + 1; i < len(expression); i++ {
+		switch c := expression[i]; {
+		case c == '"':
+			return filterToken{kind: filterStringLiteral, text: expression[offset : i+1], value: value.String(),
+				offset: offset}, nil
+		case c == '\\' && i+1 < len(expression) && (expression[i+1] == '"' || expression[i+1] == '\\'):
+			value.WriteByte(expression[i+1])
+			i++
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return filterToken{}, &FilterExpressionError{Expression: expression, Offset: offset, Message: "unterminated string"}
+}
+
+type filterType string
+
+const (
+	filterBoolean filterType = "boolean"
+	filterNumber  filterType = "number"
+	filterString  filterType = "string"
+	filterVersion filterType = "version"
+)
+
+// filterOperand is a typed node of the expression, evaluated against a JVM.
+type filterOperand struct {
+	typ filterType
+	// token is the first token of the operand, used to report errors.
+	token filterToken
+	// start and end are the byte offsets of the operand in the expression.
+	start, end int
+	// literal is true when value holds the constant value of the operand.
+	literal bool
+	value   interface{}
+	// coerce converts a literal compared to this operand, for example a string literal to a Java version.
+	coerce func(literal *filterOperand) error
+	eval   func(jvm *Jvm) interface{}
+}
+
+func (operand *filterOperand) setLiteral(typ filterType, value interface{}) {
+	operand.typ = typ
+	operand.literal = true
+	operand.value = value
+	operand.eval = func(*Jvm) interface{} { return value }
+}
+
+type filterField struct {
+	typ    filterType
+	value  func(jvm *Jvm) interface{}
+	coerce func(literal *filterOperand) error
+}
+
+var filterFields = map[string]filterField{
+	"spec":      {filterNumber, func(jvm *Jvm) interface{} { return jvm.JavaSpecificationVersion }, nil},
+	"version":   {filterVersion, func(jvm *Jvm) interface{} { return jvm.JavaVersion }, coerceVersionLiteral},
+	"vendor":    {filterString, func(jvm *Jvm) interface{} { return jvm.JavaVendor }, nil},
+	"arch":      {filterString, func(jvm *Jvm) interface{} { return jvm.OsArch }, coerceArchLiteral},
+	"home":      {filterString, func(jvm *Jvm) interface{} { return jvm.JavaHome }, nil},
+	"dataModel": {filterNumber, func(jvm *Jvm) interface{} { return jvm.DataModel }, nil},
+}
+
+type filterFunction struct {
+	typ  filterType
+	call func(jvm *Jvm, key string) interface{}
+}
+
+var filterFunctions = map[string]filterFunction{
+	"has": {filterBoolean, func(jvm *Jvm, key string) interface{} {
+		_, defined := jvm.SystemProperties[key]
+		return defined
+	}},
+	"prop": {filterString, func(jvm *Jvm, key string) interface{} { return jvm.SystemProperties[key] }},
+}
+
+func coerceVersionLiteral(literal *filterOperand) error {
+	switch literal.typ {
+	case filterString:
+		version, err := ParseJavaVersion(literal.value.(string))
+		if err != nil {
+			return err
+		}
+		literal.setLiteral(filterVersion, version)
+	case filterNumber:
+		literal.setLiteral(filterVersion, JavaVersion{Feature: literal.value.(uint)})
+	}
+	return nil
+}
+
+func coerceArchLiteral(literal *filterOperand) error {
+	if literal.typ == filterString {
+		literal.setLiteral(filterString, NormalizeArch(literal.value.(string)))
+	}
+	return nil
+}
+
+type filterParser struct {
+	expression string
+	tokens     []filterToken
+	position   int
+	// properties are the system properties read by the functions parsed so far.
+	properties []string
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.position]
+}
+
+func (p *filterParser) next() filterToken {
+	token := p.tokens[p.position]
+	if token.kind != filterEnd {
+		p.position++
+	}
+	return token
+}
+
+// accept consumes the next token if it is the given operator.
+func (p *filterParser) accept(operator string) bool {
+	if token := p.peek(); token.kind == filterOperator && token.text == operator {
+		p.position++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(operator string) (filterToken, error) {
+	token := p.next()
+	if token.kind != filterOperator || token.text != operator {
+		return token, p.error(token, "unexpected %s, expecting \"%s\"", token, operator)
+	}
+	return token, nil
+}
+
+func (p *filterParser) error(token filterToken, format string, args ...interface{}) error {
+	return &FilterExpressionError{Expression: p.expression, Offset: token.offset, Message: fmt.Sprintf(format, args...)}
+}
+
+// parseOr parses alternatives separated by ||. The operands of the top-level && operator are returned along with the
+// parsed operand, so that the mismatching ones can be reported.
+func (p *filterParser) parseOr() (*filterOperand, []*filterOperand, error) {
+	terms, err := p.parseAnd()
+	if err != nil {
+		return nil, nil, err
+	}
+	first := p.combine(terms, "&&")
+	if p.peek().text != "||" {
+		return first, terms, nil
+	}
+	alternatives := []*filterOperand{first}
+	for p.accept("||") {
+		if terms, err = p.parseAnd(); err != nil {
+			return nil, nil, err
+		}
+		alternatives = append(alternatives, p.combine(terms, "&&"))
+	}
+	if err := p.checkBooleans(alternatives, "||"); err != nil {
+		return nil, nil, err
+	}
+	or := p.combine(alternatives, "||")
+	return or, []*filterOperand{or}, nil
+}
+
+func (p *filterParser) parseAnd() ([]*filterOperand, error) {
+	var terms []*filterOperand
+	for {
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if !p.accept("&&") {
+			return terms, p.checkBooleans(terms, "&&")
+		}
+	}
+}
+
+// combine combines the boolean operands with the && or || operator.
+func (p *filterParser) combine(operands []*filterOperand, operator string) *filterOperand {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	evaluators := make([]func(jvm *Jvm) interface{}, len(operands))
+	for i, operand := range operands {
+		evaluators[i] = operand.eval
+	}
+	shortCircuit := operator == "||"
+	return &filterOperand{
+		typ:   filterBoolean,
+		token: operands[0].token,
+		start: operands[0].start,
+		end:   operands[len(operands)-1].end,
+		eval: func(jvm *Jvm) interface{} {
+			for _, eval := range evaluators {
+				if eval(jvm).(bool) == shortCircuit {
+					return shortCircuit
+				}
+			}
+			return !shortCircuit
+		},
+	}
+}
+
+func (p *filterParser) checkBooleans(operands []*filterOperand, operator string) error {
+	if len(operands) < 2 {
+		return nil
+	}
+	for _, operand := range operands {
+		if operand.typ != filterBoolean {
+			return p.error(operand.token, "\"%s\" expects boolean operands but got a %s", operator, operand.typ)
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) parseUnary() (*filterOperand, error) {
+	token := p.peek()
+	if !p.accept("!") {
+		return p.parseComparison()
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if operand.typ != filterBoolean {
+		return nil, p.error(operand.token, "\"!\" expects a boolean operand but got a %s", operand.typ)
+	}
+	eval := operand.eval
+	return &filterOperand{
+		typ:   filterBoolean,
+		token: token,
+		start: token.offset,
+		end:   operand.end,
+		eval:  func(jvm *Jvm) interface{} { return !eval(jvm).(bool) },
+	}, nil
+}
+
+func (p *filterParser) parseComparison() (*filterOperand, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	operator := p.peek()
+	switch {
+	case operator.kind == filterIdentifier && operator.text == "in":
+		p.next()
+		return p.parseIn(left)
+	case operator.kind == filterOperator && isFilterComparison(operator.text):
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return p.compare(left, operator, right)
+	}
+	return left, nil
+}
+
+func isFilterComparison(operator string) bool {
+	switch operator {
+	case "==", "!=", "<", "<=", ">", ">=", "~=":
+		return true
+	}
+	return false
+}
+
+// unify coerces the literal operand to the type of the other one, if needed, and checks that both types match.
+func (p *filterParser) unify(left *filterOperand, operator filterToken, right *filterOperand) error {
+	if left.coerce != nil && right.literal {
+		if err := left.coerce(right); err != nil {
+			return p.error(right.token, "%v", err)
+		}
+	}
+	if right.coerce != nil && left.literal {
+		if err := right.coerce(left); err != nil {
+			return p.error(left.token, "%v", err)
+		}
+	}
+	if left.typ != right.typ {
+		return p.error(operator, "cannot compare a %s with a %s", left.typ, right.typ)
+	}
+	return nil
+}
+
+func (p *filterParser) compare(left *filterOperand, operator filterToken, right *filterOperand) (*filterOperand, error) {
+	if err := p.unify(left, operator, right); err != nil {
+		return nil, err
+	}
+	leftEval := left.eval
+	rightEval := right.eval
+	result := &filterOperand{typ: filterBoolean, token: left.token, start: left.start, end: right.end}
+	switch operator.text {
+	case "~=":
+		if left.typ != filterString {
+			return nil, p.error(operator, "\"~=\" cannot be applied to a %s", left.typ)
+		}
+		if !right.literal {
+			return nil, p.error(right.token, "the right operand of \"~=\" must be a string literal")
+		}
+		pattern, err := regexp.Compile(right.value.(string))
+		if err != nil {
+			return nil, p.error(right.token, "invalid regular expression: %v", err)
+		}
+		result.eval = func(jvm *Jvm) interface{} { return pattern.MatchString(leftEval(jvm).(string)) }
+		return result, nil
+	case "<", "<=", ">", ">=":
+		if left.typ != filterNumber && left.typ != filterVersion {
+			return nil, p.error(operator, "\"%s\" cannot be applied to a %s", operator.text, left.typ)
+		}
+	}
+	accepts := map[string]func(comparison int) bool{
+		"==": func(comparison int) bool { return comparison == 0 },
+		"!=": func(comparison int) bool { return comparison != 0 },
+		"<":  func(comparison int) bool { return comparison < 0 },
+		"<=": func(comparison int) bool { return comparison <= 0 },
+		">":  func(comparison int) bool { return comparison > 0 },
+		">=": func(comparison int) bool { return comparison >= 0 },
+	}[operator.text]
+	result.eval = func(jvm *Jvm) interface{} { return accepts(compareFilterValues(leftEval(jvm), rightEval(jvm))) }
+	return result, nil
+}
+
+func (p *filterParser) parseIn(left *filterOperand) (*filterOperand, error) {
+	if _, err := p.expect("["); err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for !p.accept("]") {
+		if len(values) > 0 {
+			if _, err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		element, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if !element.literal {
+			return nil, p.error(element.token, "list elements must be literals")
+		}
+		if err := p.unify(left, element.token, element); err != nil {
+			return nil, err
+		}
+		values = append(values, element.value)
+	}
+	end := p.tokens[p.position-1]
+	leftEval := left.eval
+	return &filterOperand{
+		typ:   filterBoolean,
+		token: left.token,
+		start: left.start,
+		end:   end.offset + len(end.text),
+		eval: func(jvm *Jvm) interface{} {
+			value := leftEval(jvm)
+			for _, candidate := range values {
+				if compareFilterValues(value, candidate) == 0 {
+					return true
+				}
+			}
+			return false
+		},
+	}, nil
+}
+
+func (p *filterParser) parsePrimary() (*filterOperand, error) {
+	token := p.next()
+	operand := &filterOperand{token: token, start: token.offset, end: token.offset + len(token.text)}
+	switch token.kind {
+	case filterNumberLiteral:
+		number, err := ParseJavaSpecificationVersion(token.text)
+		if err != nil {
+			return nil, p.error(token, "invalid number %s", token.text)
+		}
+		operand.setLiteral(filterNumber, number)
+	case filterStringLiteral:
+		operand.setLiteral(filterString, token.value)
+	case filterIdentifier:
+		return p.parseIdentifier(operand)
+	case filterOperator:
+		if token.text != "(" {
+			return nil, p.error(token, "unexpected %s", token)
+		}
+		inner, _, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, err := p.expect(")")
+		if err != nil {
+			return nil, err
+		}
+		parenthesized := *inner
+		parenthesized.token = token
+		parenthesized.start = token.offset
+		parenthesized.end = closing.offset + 1
+		return &parenthesized, nil
+	default:
+		return nil, p.error(token, "unexpected %s", token)
+	}
+	return operand, nil
+}
+
+func (p *filterParser) parseIdentifier(operand *filterOperand) (*filterOperand, error) {
+	token := operand.token
+	if token.text == "true" || token.text == "false" {
+		operand.setLiteral(filterBoolean, token.text == "true")
+		return operand, nil
+	}
+	if p.peek().text != "(" {
+		field, ok := filterFields[token.text]
+		if !ok {
+			return nil, p.error(token, "unknown field \"%s\", available fields are: %s", token.text,
+				strings.Join(filterFieldNames(), ", "))
+		}
+		operand.typ = field.typ
+		operand.eval = field.value
+		operand.coerce = field.coerce
+		return operand, nil
+	}
+	function, ok := filterFunctions[token.text]
+	if !ok {
+		return nil, p.error(token, "unknown function \"%s\", available functions are: has, prop", token.text)
+	}
+	p.next()
+	argument, _, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if argument.typ != filterString {
+		return nil, p.error(argument.token, "%s() expects a string argument but got a %s", token.text, argument.typ)
+	}
+	closing, err := p.expect(")")
+	if err != nil {
+		return nil, err
+	}
+	p.addProperty(argument)
+	argumentEval := argument.eval
+	operand.typ = function.typ
+	operand.end = closing.offset + 1
+	operand.eval = func(jvm *Jvm) interface{} { return function.call(jvm, argumentEval(jvm).(string)) }
+	return operand, nil
+}
+
+// addProperty records the system property read by a function given its argument.
+func (p *filterParser) addProperty(argument *filterOperand) {
+	property := "*"
+	if argument.literal {
+		property = argument.value.(string)
+	}
+	for _, known := range p.properties {
+		if known == property {
+			return
+		}
+	}
+	p.properties = append(p.properties, property)
+}
+
+func filterFieldNames() []string {
+	var names []string
+	for name := range filterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compareFilterValues compares two values of the same type, booleans being only equal or different.
+func compareFilterValues(left interface{}, right interface{}) int {
+	switch left := left.(type) {
+	case uint:
+		right := right.(uint)
+		if left < right {
+			return -1
+		} else if left > right {
+			return 1
+		}
+		return 0
+	case JavaVersion:
+		return left.Compare(right.(JavaVersion))
+	case string:
+		return strings.Compare(left, right.(string))
+	default:
+		if left == right {
+			return 0
+		}
+		return 1
+	}
+}
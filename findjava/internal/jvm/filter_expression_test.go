@@ -0,0 +1,126 @@
+package jvm
+
+import (
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestFilterExpressionMatches(t *testing.T) {
+	jvm := Jvm{
+		JavaHome:                 "/usr/lib/jvm/temurin-17",
+		JavaSpecificationVersion: 17,
+		JavaVersion:              JavaVersion{Feature: 17, Update: 9, Build: 9},
+		JavaVendor:               "Eclipse Adoptium",
+		OsArch:                   "amd64",
+		DataModel:                64,
+		SystemProperties: map[string]string{
+			"java.vm.name": "OpenJDK 64-Bit Server VM",
+			"jdk.debug":    "release",
+		},
+	}
+	data := map[string]bool{
+		"spec >= 17":                     true,
+		"spec > 17":                      false,
+		"spec == 1.8 || spec == 17":      true,
+		"spec != 17":                     false,
+		"spec in [11, 17, 21]":           true,
+		"spec in []":                     false,
+		"version >= \"17.0.9\"":          true,
+		"version < \"17.0.10\"":          true,
+		"version > 17":                   true,
+		"version == \"17.0.9+9\"":        true,
+		"\"17.0.8\" < version":           true,
+		"vendor == \"Eclipse Adoptium\"": true,
+		"vendor in [\"Eclipse Adoptium\", \"Azul Systems, Inc.\"]": true,
+		"vendor ~= \"^Azul\"":                false,
+		"arch == \"x86_64\"":                 true,
+		"arch in [\"arm64\"]":                false,
+		"dataModel == 64":                    true,
+		"home ~= \"temurin\"":                true,
+		"has(\"jdk.debug\")":                 true,
+		"!has(\"jdk.debug\")":                false,
+		"!!has(\"jdk.debug\")":               true,
+		"has(\"java.vm.vendor\")":            false,
+		"prop(\"jdk.debug\") == \"release\"": true,
+		"prop(\"java.vm.vendor\") == \"\"":   true,
+		"prop(\"java.vm.name\") ~= \"Zero\"": false,
+		"true":                               true,
+		"!(spec >= 17 && dataModel == 32)":   true,
+		"spec >= 17 && (vendor == \"Oracle\" || arch == \"amd64\")":             true,
+		"spec >= 17 && vendor in [\"Eclipse Adoptium\"] && !has(\"jdk.debug\")": false,
+		"has(\"jdk.debug\") == true":                                            true,
+		"vendor == \"Eclipse \\\"Adoptium\\\"\"":                                false,
+	}
+	for expression, expected := range data {
+		parsed, err := ParseFilterExpression(expression)
+		description := fmt.Sprintf("ParseFilterExpression(`%s`)", expression)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".Matches()", expected, parsed.Matches(&jvm))
+		test.AssertEquals(t, description+".String()", expression, parsed.String())
+	}
+}
+
+func TestFilterExpressionMismatches(t *testing.T) {
+	jvm := Jvm{JavaSpecificationVersion: 11, JavaVendor: "Eclipse Adoptium", SystemProperties: map[string]string{"jdk.debug": "release"}}
+	data := map[string][]string{
+		"spec >= 17 && vendor in [\"Eclipse Adoptium\"] && !has(\"jdk.debug\")": {"spec >= 17", "!has(\"jdk.debug\")"},
+		"spec >= 17 || (vendor == \"Oracle\")":                                  {"spec >= 17 || (vendor == \"Oracle\")"},
+		" ( spec >= 17 ) && spec < 21 ":                                         {"( spec >= 17 )"},
+		"spec >= 11":                                                            nil,
+	}
+	for expression, expected := range data {
+		parsed, err := ParseFilterExpression(expression)
+		description := fmt.Sprintf("ParseFilterExpression(`%s`)", expression)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".Mismatches()", expected, parsed.Mismatches(&jvm))
+	}
+}
+
+func TestFilterExpressionProperties(t *testing.T) {
+	data := map[string][]string{
+		"spec >= 17": nil,
+		"prop(\"java.vm.name\") ~= \"OpenJ9\" && !has(\"jdk.debug\")": {"java.vm.name", "jdk.debug"},
+		"has(\"jdk.debug\") || prop(\"jdk.debug\") == \"release\"":    {"jdk.debug"},
+		"prop(prop(\"findjava.property\")) == \"true\"":               {"*", "findjava.property"},
+	}
+	for expression, expected := range data {
+		parsed, err := ParseFilterExpression(expression)
+		description := fmt.Sprintf("ParseFilterExpression(`%s`)", expression)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".Properties()", expected, parsed.Properties())
+	}
+}
+
+func TestParseInvalidFilterExpression(t *testing.T) {
+	data := map[string]string{
+		"":                      "expression is empty at column 1\n\t\n\t^",
+		"spec >= ":              "unexpected end of expression at column 9\n\tspec >= \n\t        ^",
+		"spec >= 17 &&":         "unexpected end of expression at column 14\n\tspec >= 17 &&\n\t             ^",
+		"spec >= 17 vendor":     "unexpected identifier vendor at column 12\n\tspec >= 17 vendor\n\t           ^",
+		"spec = 17":             "unexpected character '=' at column 6\n\tspec = 17\n\t     ^",
+		"vendor == \"Azul":      "unterminated string at column 11\n\tvendor == \"Azul\n\t          ^",
+		"vendr == \"Azul\"":     "unknown field \"vendr\", available fields are: arch, dataModel, home, spec, vendor, version at column 1",
+		"exists(\"jdk.debug\")": "unknown function \"exists\", available functions are: has, prop at column 1",
+		"spec == \"17\"":        "cannot compare a number with a string at column 6",
+		"spec":                  "expecting a boolean expression but got a number at column 1",
+		"spec >= 17 && vendor":  "\"&&\" expects boolean operands but got a string at column 15",
+		"!vendor":               "\"!\" expects a boolean operand but got a string at column 2",
+		"vendor > \"Azul\"":     "\">\" cannot be applied to a string at column 8",
+		"spec ~= \"1.*\"":       "cannot compare a number with a string at column 6",
+		"vendor ~= vendor":      "the right operand of \"~=\" must be a string literal at column 11",
+		"vendor ~= \"(\"":       "invalid regular expression: error parsing regexp: missing closing ): `(` at column 11",
+		"version >= \"17.0.x\"": "Java version '17.0.x' cannot be parsed at column 12",
+		"spec in [11, spec]":    "list elements must be literals at column 14",
+		"spec in [11 17]":       "unexpected number 17, expecting \",\" at column 13",
+		"spec in 11":            "unexpected number 11, expecting \"[\" at column 9",
+		"has(17)":               "has() expects a string argument but got a number at column 5",
+		"(spec >= 17":           "unexpected end of expression, expecting \")\" at column 12",
+		"spec >= 1.2.3":         "invalid number 1.2.3 at column 9",
+	}
+	for expression, expectedError := range data {
+		_, err := ParseFilterExpression(expression)
+		test.AssertErrorContains(t, fmt.Sprintf("ParseFilterExpression(`%s`)", expression),
+			"invalid filter expression: "+expectedError, err)
+	}
+}
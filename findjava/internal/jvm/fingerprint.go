@@ -0,0 +1,64 @@
+package jvm
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// FileFingerprint identifies the content of a file without reading it.
+type FileFingerprint struct {
+	Inode   uint64
+	Size    int64
+	ModTime time.Time
+}
+
+// Fingerprint identifies an installed JVM so that upgrades are detected even when they preserve the
+// modification time of the java executable. Release and Modules are nil when the JVM does not have
+// a release file or a lib/modules image (Java 8 and older).
+type Fingerprint struct {
+	Java    *FileFingerprint
+	Release *FileFingerprint
+	Modules *FileFingerprint
+}
+
+// Equals returns true if both fingerprints identify the same files.
+func (fingerprint *Fingerprint) Equals(other *Fingerprint) bool {
+	if fingerprint == nil || other == nil {
+		return fingerprint == other
+	}
+	return fileFingerprintEquals(fingerprint.Java, other.Java) &&
+		fileFingerprintEquals(fingerprint.Release, other.Release) &&
+		fileFingerprintEquals(fingerprint.Modules, other.Modules)
+}
+
+func fileFingerprintEquals(fingerprint *FileFingerprint, other *FileFingerprint) bool {
+	if fingerprint == nil || other == nil {
+		return fingerprint == other
+	}
+	return fingerprint.Inode == other.Inode && fingerprint.Size == other.Size &&
+		fingerprint.ModTime.Equal(other.ModTime)
+}
+
+// computeFingerprint fingerprints the java executable and the release and lib/modules files of its java.home.
+func computeFingerprint(javaPath string, javaHome string) *Fingerprint {
+	return &Fingerprint{
+		Java:    fileFingerprint(javaPath),
+		Release: fileFingerprint(filepath.Join(javaHome, releaseFileName)),
+		Modules: fileFingerprint(filepath.Join(javaHome, "lib", "modules")),
+	}
+}
+
+// fileFingerprint returns nil if the file does not exist.
+func fileFingerprint(path string) *FileFingerprint {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	fingerprint := &FileFingerprint{Size: fileInfo.Size(), ModTime: fileInfo.ModTime()}
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		fingerprint.Inode = uint64(stat.Ino)
+	}
+	return fingerprint
+}
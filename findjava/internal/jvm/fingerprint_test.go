@@ -0,0 +1,38 @@
+package jvm
+
+import (
+	"findjava/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJvmIsOutdated(t *testing.T) {
+	javaHome, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(javaHome) }()
+	javaPath := filepath.Join(javaHome, "bin", "java")
+	releasePath := filepath.Join(javaHome, "release")
+	test.AssertNoError(t, "os.MkdirAll()", os.MkdirAll(filepath.Dir(javaPath), 0755))
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(javaPath, []byte("java"), 0755))
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(releasePath, []byte(`JAVA_VERSION="17.0.8"`), 0644))
+	jvm := Jvm{javaPath: javaPath, JavaHome: javaHome, Fingerprint: computeFingerprint(javaPath, javaHome)}
+	test.AssertEquals(t, "IsOutdated()", false, jvm.IsOutdated())
+
+	// An upgrade replacing the release file, as package managers do, but preserving its modification time
+	modTime := time.Now().Add(-time.Hour)
+	test.AssertNoError(t, "os.Chtimes()", os.Chtimes(releasePath, modTime, modTime))
+	jvm.Fingerprint = computeFingerprint(javaPath, javaHome)
+	newReleasePath := releasePath + ".new"
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(newReleasePath, []byte(`JAVA_VERSION="17.0.9"`), 0644))
+	test.AssertNoError(t, "os.Chtimes()", os.Chtimes(newReleasePath, modTime, modTime))
+	test.AssertNoError(t, "os.Rename()", os.Rename(newReleasePath, releasePath))
+	test.AssertEquals(t, "IsOutdated() after upgrade", true, jvm.IsOutdated())
+
+	jvm.Fingerprint = computeFingerprint(javaPath, javaHome)
+	test.AssertEquals(t, "IsOutdated()", false, jvm.IsOutdated())
+	test.AssertNoError(t, "os.Remove()", os.Remove(javaPath))
+	test.AssertEquals(t, "IsOutdated() after removal", true, jvm.IsOutdated())
+}
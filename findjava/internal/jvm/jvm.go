@@ -1,7 +1,9 @@
 package jvm
 
 import (
+	"findjava/internal/log"
 	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -9,9 +11,27 @@ type Jvm struct {
 	javaPath                 string
 	JavaHome                 string
 	JavaSpecificationVersion uint
+	JavaVersion              JavaVersion
 	JavaVendor               string
+	OsArch                   string
+	DataModel                uint
 	FetchedAt                time.Time
-	SystemProperties         map[string]string
+	// Strategy is the metadata strategy which fetched the metadata.
+	Strategy         MetadataStrategy
+	Fingerprint      *Fingerprint
+	SystemProperties map[string]string
+}
+
+// JavaPath returns the path of the java executable this JVM has been discovered from.
+func (jvm *Jvm) JavaPath() string {
+	return jvm.javaPath
+}
+
+// IsOutdated returns true when the java executable has been removed or when it, the release file or
+// the lib/modules file has been modified or replaced since the metadata were fetched.
+func (jvm *Jvm) IsOutdated() bool {
+	fingerprint := computeFingerprint(jvm.javaPath, jvm.JavaHome)
+	return fingerprint.Java == nil || !fingerprint.Equals(jvm.Fingerprint)
 }
 
 func (jvm *Jvm) rebuild() error {
@@ -22,18 +42,52 @@ func (jvm *Jvm) rebuild() error {
 	} else {
 		jvm.JavaSpecificationVersion = specVersion
 	}
+	jvm.JavaVersion = jvm.parseJavaVersion()
+	jvm.OsArch = NormalizeArch(jvm.SystemProperties["os.arch"])
+	jvm.DataModel = jvm.parseDataModel()
 	return nil
 }
 
+// parseDataModel parses sun.arch.data.model, which is not provided by every JVM and can be "unknown".
+// Zero is returned when the data model is not known.
+func (jvm *Jvm) parseDataModel() uint {
+	if value, found := jvm.SystemProperties["sun.arch.data.model"]; found {
+		if dataModel, err := strconv.ParseUint(value, 10, 32); err == nil {
+			return uint(dataModel)
+		}
+		log.Debug("Unable to parse sun.arch.data.model '%s' of JVM %s", value, jvm.javaPath)
+	}
+	return 0
+}
+
+// parseJavaVersion parses the most precise version property available.
+// Failures are not fatal as the full version is only used for update level constraints and ordering.
+func (jvm *Jvm) parseJavaVersion() JavaVersion {
+	for _, property := range []string{"java.runtime.version", "java.version"} {
+		if value, found := jvm.SystemProperties[property]; found {
+			if version, err := ParseJavaVersion(value); err == nil {
+				return version
+			} else {
+				log.Debug("Unable to parse %s of JVM %s: %v", property, jvm.javaPath, err)
+			}
+		}
+	}
+	return JavaVersion{Feature: jvm.JavaSpecificationVersion}
+}
+
 func (jvm *Jvm) String() string {
 	return fmt.Sprintf(
 		`[%v]
 timestamp: %s
 java.home: %s
 java.specification.version: %d
+java.version: %s
+os.arch: %s
 `,
 		jvm.javaPath,
 		jvm.FetchedAt,
 		jvm.JavaHome,
-		jvm.JavaSpecificationVersion)
+		jvm.JavaSpecificationVersion,
+		jvm.JavaVersion,
+		jvm.OsArch)
 }
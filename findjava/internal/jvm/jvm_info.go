@@ -2,39 +2,246 @@ package jvm
 
 import (
 	"encoding/json"
+	"errors"
 	. "findjava/internal/discovery"
 	"findjava/internal/log"
 	"findjava/internal/utils"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 )
 
+// CachePolicy defines how LoadJvmsInfos uses the JVMs metadata cache.
+type CachePolicy int
+
+const (
+	// CacheEnabled reuses the up-to-date cached metadata and only fetches the missing or outdated ones.
+	CacheEnabled CachePolicy = iota
+	// CacheRefresh ignores the cached metadata of the discovered JVMs, fetches them all and updates the cache.
+	CacheRefresh
+	// CacheDisabled neither reads nor writes the cache.
+	CacheDisabled
+	// CacheRebuild discards the cache, fetches the metadata of every discovered JVM and writes a cache
+	// containing only them.
+	CacheRebuild
+)
+
+// cacheSchemaVersion is the version of the cache format.
+// It must be incremented whenever Jvm or the way its metadata are extracted changes.
+const cacheSchemaVersion = 6
+
+// CacheHeader describes how the cached metadata have been produced.
+// The cache is discarded when it does not match the header of the running findjava.
+type CacheHeader struct {
+	SchemaVersion   int
+	FindjavaVersion string
+	ExtractorHash   string
+	// Properties are the patterns of the extracted system properties.
+	Properties string
+}
+
 type JvmsInfos struct {
-	path       string
-	dirtyCache bool
-	fetched    map[string]bool
-	Jvms       map[string]*Jvm
+	path           string
+	dirtyCache     bool
+	fetched        map[string]bool
+	metadataReader *MetadataReader
+	Header         CacheHeader
+	Jvms           map[string]*Jvm
+	// BrokenJvms are the JVMs whose metadata could not be fetched. They are skipped until their files change.
+	BrokenJvms map[string]*BrokenJvm
+}
+
+// BrokenJvm records the failure to fetch the metadata of a JVM.
+type BrokenJvm struct {
+	javaPath    string
+	Error       string
+	FailedAt    time.Time
+	Fingerprint *Fingerprint
+}
+
+// JavaPath returns the path of the java executable of the broken JVM.
+func (jvm *BrokenJvm) JavaPath() string {
+	return jvm.javaPath
+}
+
+// IsOutdated returns true when the files of the broken JVM have changed since the failure,
+// meaning that fetching its metadata again may succeed.
+func (jvm *BrokenJvm) IsOutdated() bool {
+	fingerprint := computeFingerprint(jvm.javaPath, filepath.Dir(filepath.Dir(jvm.javaPath)))
+	return fingerprint.Java == nil || !fingerprint.Equals(jvm.Fingerprint)
 }
 
-func LoadJvmsInfos(metadataReader *MetadataReader, cachePath string, javaPaths *JavaExecutables) (JvmsInfos, error) {
-	jvmInfos := loadJvmsInfosFromCache(cachePath)
-	for javaPath, modTime := range javaPaths.JavaPaths {
-		if err := jvmInfos.Fetch(metadataReader, javaPath, modTime); err != nil {
-			return JvmsInfos{}, err
+func (jvm *BrokenJvm) err() error {
+	return &ExtractorError{JavaPath: jvm.javaPath, Err: errors.New(jvm.Error)}
+}
+
+// LoadJvmsInfos returns the metadata of the discovered JVMs. With the CacheEnabled policy, the up-to-date metadata
+// of the read-only system cache are used first, then those of the cache. An empty systemCachePath disables the
+// system cache. The discovered JVMs which are broken are reported as warnings, see CheckUsableJvms.
+// The cache is exclusively locked until it is saved, so that concurrent runs do not drop the metadata fetched by
+// each other.
+func LoadJvmsInfos(metadataReader *MetadataReader, cachePath string, systemCachePath string,
+	cachePolicy CachePolicy, javaPaths *JavaExecutables) JvmsInfos {
+	if cachePolicy != CacheDisabled {
+		lock := lockCacheForUpdate(cachePath)
+		defer lock.Unlock()
+	}
+	var jvmInfos JvmsInfos
+	if cachePolicy == CacheDisabled || cachePolicy == CacheRebuild {
+		jvmInfos = newJvmsInfos(cachePath)
+	} else {
+		jvmInfos = readJvmsInfos(cachePath)
+	}
+	header := metadataReader.cacheHeader()
+	if jvmInfos.Header != header {
+		if len(jvmInfos.Jvms) > 0 || len(jvmInfos.BrokenJvms) > 0 {
+			log.Info("[CACHE INVALIDATED] %s was written with %+v, expecting %+v", cachePath, jvmInfos.Header, header)
+			jvmInfos = newJvmsInfos(cachePath)
+		}
+		jvmInfos.dirtyCache = true
+	}
+	jvmInfos.Header = header
+	jvmInfos.metadataReader = metadataReader
+	var systemCache *JvmsInfos
+	if cachePolicy == CacheEnabled && systemCachePath != "" && systemCachePath != cachePath {
+		systemCache = loadSystemCache(systemCachePath, header)
+	}
+	var toFetch []string
+	discovered := sortedJavaPaths(javaPaths)
+	for _, javaPath := range discovered {
+		jvmInfos.fetched[javaPath] = true
+		if cachePolicy == CacheRefresh || cachePolicy == CacheRebuild {
+			log.Info("[CACHE REFRESH] %s", javaPath)
+			toFetch = append(toFetch, javaPath)
+		} else if jvmInfos.useSystemCache(systemCache, javaPath) {
+			log.Info("[SYSTEM CACHE HIT] %s", javaPath)
+		} else if broken, found := jvmInfos.BrokenJvms[javaPath]; found && !broken.IsOutdated() {
+			log.Info("[CACHE BROKEN] %s", javaPath)
+		} else if jvmInfos.isStale(javaPath) {
+			toFetch = append(toFetch, javaPath)
 		}
 	}
-	_ = jvmInfos.Save()
-	return jvmInfos, nil
+	jvmInfos.fetchAll(metadataReader, toFetch)
+	if cachePolicy != CacheDisabled {
+		_ = jvmInfos.save()
+	}
+	jvmInfos.warnBrokenJvms(discovered)
+	return jvmInfos
 }
 
-func loadJvmsInfosFromCache(path string) JvmsInfos {
-	jvmsInfos := JvmsInfos{
+// loadSystemCache loads the system cache, returning nil when it does not exist or was not written by the
+// running findjava.
+func loadSystemCache(path string, header CacheHeader) *JvmsInfos {
+	if _, err := os.Stat(path); err != nil {
+		log.Debug("System cache %s not found: %v", path, err)
+		return nil
+	}
+	systemCache := loadJvmsInfosFromCache(path)
+	if systemCache.Header != header {
+		log.Info("[SYSTEM CACHE IGNORED] %s was written with %+v, expecting %+v", path, systemCache.Header, header)
+		return nil
+	}
+	return &systemCache
+}
+
+// useSystemCache uses the up-to-date metadata of the JVM from the system cache if any.
+// The system cache itself is never written.
+func (jvms *JvmsInfos) useSystemCache(systemCache *JvmsInfos, javaPath string) bool {
+	if systemCache == nil {
+		return false
+	}
+	if jvm, found := systemCache.Jvms[javaPath]; found && !jvm.IsOutdated() &&
+		jvms.metadataReader.providesReferencedProperties(jvm) {
+		jvms.Jvms[javaPath] = jvm
+		delete(jvms.BrokenJvms, javaPath)
+		return true
+	}
+	if broken, found := systemCache.BrokenJvms[javaPath]; found && !broken.IsOutdated() {
+		jvms.BrokenJvms[javaPath] = broken
+		delete(jvms.Jvms, javaPath)
+		return true
+	}
+	return false
+}
+
+// warnBrokenJvms warns about the discovered JVMs which are broken.
+func (jvms *JvmsInfos) warnBrokenJvms(discovered []string) {
+	for _, javaPath := range discovered {
+		if broken, found := jvms.BrokenJvms[javaPath]; found {
+			log.Warn(log.WrapErr(broken.err(), "skipping JVM %s which could not be inspected, "+
+				"it will be inspected again once its files change", javaPath))
+		}
+	}
+}
+
+// CheckUsableJvms fails with the errors of the broken JVMs when every discovered JVM is broken.
+func (jvms *JvmsInfos) CheckUsableJvms() error {
+	var discovered []string
+	for javaPath := range jvms.fetched {
+		discovered = append(discovered, javaPath)
+	}
+	sort.Strings(discovered)
+	var errs []error
+	for _, javaPath := range discovered {
+		if broken, found := jvms.BrokenJvms[javaPath]; found {
+			errs = append(errs, broken.err())
+		}
+	}
+	if len(errs) > 0 && len(errs) == len(discovered) {
+		return newExtractorErrors(errs)
+	}
+	return nil
+}
+
+func sortedJavaPaths(javaPaths *JavaExecutables) []string {
+	var sorted []string
+	for javaPath := range javaPaths.JavaPaths {
+		sorted = append(sorted, javaPath)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// LoadCache loads the JVMs metadata from the cache without fetching nor updating anything.
+func LoadCache(path string) JvmsInfos {
+	return loadJvmsInfosFromCache(path)
+}
+
+// ClearCache deletes the JVMs metadata cache. Clearing a non-existing cache is not an error.
+func ClearCache(path string) error {
+	if _, err := os.Stat(filepath.Dir(path)); err == nil {
+		lock := lockCache(path, true)
+		defer lock.Unlock()
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return log.WrapErr(err, "unable to delete cache %s", path)
+	}
+	return nil
+}
+
+func newJvmsInfos(path string) JvmsInfos {
+	return JvmsInfos{
 		path:       path,
 		dirtyCache: false,
 		fetched:    make(map[string]bool),
 		Jvms:       make(map[string]*Jvm),
+		BrokenJvms: make(map[string]*BrokenJvm),
 	}
+}
+
+func loadJvmsInfosFromCache(path string) JvmsInfos {
+	if _, err := os.Stat(path); err == nil {
+		lock := lockCache(path, false)
+		defer lock.Unlock()
+	}
+	return readJvmsInfos(path)
+}
+
+// readJvmsInfos reads the cache without locking it.
+func readJvmsInfos(path string) JvmsInfos {
+	jvmsInfos := newJvmsInfos(path)
 	// Failures to load will from cache will result in an empty JvmsInfos
 	// which will cause every discovered JVM to be fetched
 	if _, err := os.Stat(path); err == nil {
@@ -43,6 +250,12 @@ func loadJvmsInfosFromCache(path string) JvmsInfos {
 			defer utils.CloseFile(file)
 			decoder := json.NewDecoder(file)
 			if err := decoder.Decode(&jvmsInfos); err == nil {
+				if jvmsInfos.BrokenJvms == nil {
+					jvmsInfos.BrokenJvms = make(map[string]*BrokenJvm)
+				}
+				for javaPath, broken := range jvmsInfos.BrokenJvms {
+					broken.javaPath = javaPath
+				}
 				for javaPath, jvm := range jvmsInfos.Jvms {
 					jvm.javaPath = javaPath
 					if err := jvm.rebuild(); err != nil {
@@ -52,47 +265,131 @@ func loadJvmsInfosFromCache(path string) JvmsInfos {
 				}
 				//log.Debug("JVMs rebuilt loaded from cache: %#v", jvmsInfos)
 			} else {
-				log.Warn(log.WrapErr(err, "cannot read config file %s:", path))
+				log.Warn(log.WrapErr(err, "cache %s is corrupted and will be rebuilt:", path))
+				jvmsInfos = newJvmsInfos(path)
+				jvmsInfos.dirtyCache = true
 			}
 		} else {
-			log.Warn(log.WrapErr(err, "cannot read config file %s:", path))
+			log.Warn(log.WrapErr(err, "cannot read cache %s:", path))
 		}
 	}
 	return jvmsInfos
 }
 
-func (jvms *JvmsInfos) Fetch(metadataReader *MetadataReader, javaPath string, modTime time.Time) error {
-	jvms.fetched[javaPath] = true
+// lockCache acquires a lock on the cache, shared for readers and exclusive for writers.
+// Failures to acquire the lock are logged and nil is returned, the cache being then used without lock.
+func lockCache(path string, exclusive bool) *utils.FileLock {
+	lock, err := utils.LockFile(path+".lock", exclusive)
+	if err != nil {
+		log.Debug("Unable to lock cache %s, using it without lock: %v", path, err)
+		return nil
+	}
+	return lock
+}
+
+// lockCacheForUpdate creates the directory of the cache if needed and acquires an exclusive lock on the cache.
+func lockCacheForUpdate(path string) *utils.FileLock {
+	if err := utils.CreateDirectory(filepath.Dir(path)); err != nil {
+		log.Debug("Unable to create the directory of cache %s, using it without lock: %v", path, err)
+		return nil
+	}
+	return lockCache(path, true)
+}
+
+// isStale returns true if the JVM is not cached or has been modified since its metadata were fetched.
+func (jvms *JvmsInfos) isStale(javaPath string) bool {
 	if info, found := jvms.Jvms[javaPath]; !found {
 		log.Info("[CACHE MISS] %s", javaPath)
-		return jvms.doFetch(metadataReader, javaPath)
-	} else if modTime.After(info.FetchedAt) {
+		return true
+	} else if info.IsOutdated() {
 		log.Info("[CACHE OUTDATED] %s", javaPath)
-		return jvms.doFetch(metadataReader, javaPath)
-	} else {
-		return nil
+		return true
+	} else if !jvms.metadataReader.providesReferencedProperties(info) {
+		log.Info("[CACHE INCOMPLETE] %s lacks the properties %v", javaPath,
+			jvms.metadataReader.missingReleaseFileProperties())
+		return true
 	}
+	return false
 }
 
 func (jvms *JvmsInfos) doFetch(metadataReader *MetadataReader, javaPath string) error {
 	jvm, err := metadataReader.fetchJvmInfo(javaPath)
 	if err != nil {
+		jvms.addBroken(javaPath, err)
 		return err
 	}
+	jvms.add(javaPath, jvm)
+	return nil
+}
+
+func (jvms *JvmsInfos) add(javaPath string, jvm *Jvm) {
 	log.Debug("%s:\n%s", javaPath, jvm)
 	jvms.Jvms[javaPath] = jvm
+	delete(jvms.BrokenJvms, javaPath)
 	jvms.dirtyCache = true
-	return nil
 }
 
+func (jvms *JvmsInfos) addBroken(javaPath string, err error) {
+	log.Debug("%s is broken: %v", javaPath, err)
+	jvms.BrokenJvms[javaPath] = &BrokenJvm{
+		javaPath:    javaPath,
+		Error:       err.Error(),
+		FailedAt:    time.Now(),
+		Fingerprint: computeFingerprint(javaPath, filepath.Dir(filepath.Dir(javaPath))),
+	}
+	delete(jvms.Jvms, javaPath)
+	jvms.dirtyCache = true
+}
+
+// fetchAll fetches the metadata of the JVMs with at most metadataReader.Concurrency JVMs fetched at the same time.
+// The results are merged in the order of the java paths, the failures being recorded as broken JVMs.
+func (jvms *JvmsInfos) fetchAll(metadataReader *MetadataReader, javaPaths []string) {
+	type fetchResult struct {
+		jvm *Jvm
+		err error
+	}
+	results := make([]fetchResult, len(javaPaths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < metadataReader.concurrency(len(javaPaths)); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				jvm, err := metadataReader.fetchJvmInfo(javaPaths[i])
+				results[i] = fetchResult{jvm: jvm, err: err}
+			}
+		}()
+	}
+	for i := range javaPaths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	for i, result := range results {
+		if result.err != nil {
+			jvms.addBroken(javaPaths[i], result.err)
+		} else {
+			jvms.add(javaPaths[i], result.jvm)
+		}
+	}
+}
+
+// Save writes the metadata to the cache if they changed, holding an exclusive lock on it.
 func (jvms *JvmsInfos) Save() error {
+	lock := lockCacheForUpdate(jvms.path)
+	defer lock.Unlock()
+	return jvms.save()
+}
+
+// save writes the metadata to the cache if they changed, the caller holding the exclusive lock on it.
+func (jvms *JvmsInfos) save() error {
 	for javaPath, jvmInfo := range jvms.Jvms {
 		if value, found := jvms.fetched[javaPath]; !found || !value {
-			if fileInfo, err := os.Stat(javaPath); err == nil {
-				if fileInfo.ModTime().After(jvmInfo.FetchedAt) {
-					if err := jvms.doFetch(nil, javaPath); err != nil {
-						return err
-					}
+			if _, err := os.Stat(javaPath); err == nil {
+				if jvmInfo.IsOutdated() && jvms.metadataReader != nil {
+					// Failures are recorded as broken JVMs
+					_ = jvms.doFetch(jvms.metadataReader, javaPath)
 				}
 			} else {
 				delete(jvms.Jvms, javaPath)
@@ -100,6 +397,12 @@ func (jvms *JvmsInfos) Save() error {
 			}
 		}
 	}
+	for javaPath := range jvms.BrokenJvms {
+		if _, err := os.Stat(javaPath); err != nil {
+			delete(jvms.BrokenJvms, javaPath)
+			jvms.dirtyCache = true
+		}
+	}
 	if jvms.dirtyCache {
 		return writeToJson(jvms)
 	}
@@ -115,7 +418,7 @@ func writeToJson(jvmInfos *JvmsInfos) error {
 	if err := utils.CreateDirectory(filepath.Dir(jvmInfos.path)); err != nil {
 		return log.WrapErr(err, "unable to create directory to host cache %s", jvmInfos.path)
 	}
-	if err := utils.WriteFile(jvmInfos.path, file, 0644); err != nil {
+	if err := utils.WriteFileAtomic(jvmInfos.path, file, 0644); err != nil {
 		return log.WrapErr(err, "unable to write to file %s", jvmInfos.path)
 	}
 	return nil
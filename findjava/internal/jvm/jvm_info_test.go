@@ -0,0 +1,251 @@
+package jvm
+
+import (
+	"encoding/json"
+	"errors"
+	. "findjava/internal/discovery"
+	"findjava/test"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFetchAll(t *testing.T) {
+	javaPaths := []string{
+		"test-resources/jdk-17/bin/java",
+		"test-resources/missing-jdk-1/bin/java",
+		"test-resources/missing-jdk-2/bin/java",
+	}
+	for _, concurrency := range []int{0, 1, 2, 10} {
+		jvms := newJvmsInfos("")
+		jvms.fetchAll(&MetadataReader{Concurrency: concurrency}, javaPaths)
+		test.AssertEquals(t, "len(BrokenJvms)", 2, len(jvms.BrokenJvms))
+		for _, javaPath := range javaPaths[1:] {
+			broken, found := jvms.BrokenJvms[javaPath]
+			if !found {
+				t.Fatalf("Expecting %s to be recorded as broken", javaPath)
+			}
+			test.AssertEquals(t, "BrokenJvm.JavaPath()", javaPath, broken.JavaPath())
+		}
+		test.AssertEquals(t, "len(Jvms)", 1, len(jvms.Jvms))
+		test.AssertEquals(t, "Jvms[jdk-17].JavaSpecificationVersion", uint(17),
+			jvms.Jvms["test-resources/jdk-17/bin/java"].JavaSpecificationVersion)
+	}
+}
+
+func TestLoadCorruptedCache(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	path := filepath.Join(directory, "findjava.json")
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(path, []byte(`{"Jvms": {"/jvm/bin/java": {`), 0644))
+	jvms := loadJvmsInfosFromCache(path)
+	test.AssertEquals(t, "len(Jvms)", 0, len(jvms.Jvms))
+	test.AssertEquals(t, "dirtyCache", true, jvms.dirtyCache)
+	test.AssertNoError(t, "Save()", jvms.Save())
+	content, err := ioutil.ReadFile(path)
+	test.AssertNoError(t, "ioutil.ReadFile()", err)
+	if !json.Valid(content) {
+		t.Fatalf("Expecting the corrupted cache to be rebuilt but got %s", content)
+	}
+}
+
+func TestLoadJvmsInfosInvalidatesCache(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	path := filepath.Join(directory, "findjava.json")
+	javaPath := "test-resources/jdk-17/bin/java"
+	javaPaths := &JavaExecutables{JavaPaths: map[string]time.Time{javaPath: time.Unix(0, 0)}}
+	reader := &MetadataReader{Version: "2.0.0"}
+	data := map[string]string{
+		"2.0.0": "Cached Vendor",
+		"1.0.0": "Eclipse Adoptium",
+	}
+	for cachedVersion, expectedVendor := range data {
+		header := reader.cacheHeader()
+		header.FindjavaVersion = cachedVersion
+		cached := JvmsInfos{Header: header, Jvms: map[string]*Jvm{javaPath: {
+			FetchedAt:   time.Now(),
+			Fingerprint: computeFingerprint(javaPath, "test-resources/jdk-17"),
+			SystemProperties: map[string]string{
+				"java.home":                  "test-resources/jdk-17",
+				"java.specification.version": "17",
+				"java.vendor":                "Cached Vendor",
+			},
+		}}}
+		content, _ := json.Marshal(cached)
+		test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(path, content, 0644))
+		jvms := LoadJvmsInfos(reader, path, "", CacheEnabled, javaPaths)
+		err := jvms.CheckUsableJvms()
+		description := fmt.Sprintf("LoadJvmsInfos() with a cache written by findjava %s", cachedVersion)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".JavaVendor", expectedVendor, jvms.Jvms[javaPath].JavaVendor)
+		test.AssertEquals(t, description+".Header", reader.cacheHeader(), LoadCache(path).Header)
+	}
+}
+
+func TestLoadJvmsInfosSkipsBrokenJvms(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	path := filepath.Join(directory, "findjava.json")
+	brokenPath := filepath.Join(directory, "broken-jdk", "bin", "java")
+	test.AssertNoError(t, "os.MkdirAll()", os.MkdirAll(filepath.Dir(brokenPath), 0755))
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(brokenPath, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	reader := &MetadataReader{Version: "2.0.0"}
+
+	// All the JVMs are broken
+	brokenOnly := &JavaExecutables{JavaPaths: map[string]time.Time{brokenPath: time.Unix(0, 0)}}
+	jvms := LoadJvmsInfos(reader, path, "", CacheEnabled, brokenOnly)
+	test.AssertEquals(t, "len(BrokenJvms)", 1, len(jvms.BrokenJvms))
+	err = jvms.CheckUsableJvms()
+	var extractorError *ExtractorError
+	if !errors.As(err, &extractorError) {
+		t.Fatalf("Expecting LoadJvmsInfos to fail with an ExtractorError but got %#v", err)
+	}
+	test.AssertEquals(t, "ExtractorError.JavaPath", brokenPath, extractorError.JavaPath)
+	failedAt := LoadCache(path).BrokenJvms[brokenPath].FailedAt
+
+	// The broken JVM is skipped until its files change
+	javaPaths := &JavaExecutables{JavaPaths: map[string]time.Time{
+		brokenPath:                       time.Unix(0, 0),
+		"test-resources/jdk-17/bin/java": time.Unix(0, 0),
+	}}
+	jvms = LoadJvmsInfos(reader, path, "", CacheEnabled, javaPaths)
+	test.AssertNoError(t, "LoadJvmsInfos() with a broken JVM", jvms.CheckUsableJvms())
+	test.AssertEquals(t, "len(Jvms)", 1, len(jvms.Jvms))
+	test.AssertEquals(t, "BrokenJvms[broken-jdk].FailedAt", failedAt, LoadCache(path).BrokenJvms[brokenPath].FailedAt)
+
+	// The broken JVM is inspected again once its files change
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(brokenPath, []byte("#!/bin/sh\nexit 2\n"), 0755))
+	jvms = LoadJvmsInfos(reader, path, "", CacheEnabled, javaPaths)
+	test.AssertNoError(t, "LoadJvmsInfos() with an upgraded broken JVM", jvms.CheckUsableJvms())
+	broken := LoadCache(path).BrokenJvms[brokenPath]
+	if !broken.FailedAt.After(failedAt) {
+		t.Fatalf("Expecting the upgraded broken JVM to be inspected again but it failed at %s", broken.FailedAt)
+	}
+}
+
+func TestLoadJvmsInfosInvalidatesBrokenJvms(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	path := filepath.Join(directory, "findjava.json")
+	javaPath := "test-resources/jdk-17/bin/java"
+	javaPaths := &JavaExecutables{JavaPaths: map[string]time.Time{javaPath: time.Unix(0, 0)}}
+	reader := &MetadataReader{Version: "2.0.0"}
+	header := reader.cacheHeader()
+	header.FindjavaVersion = "1.0.0"
+	cached := JvmsInfos{Header: header, BrokenJvms: map[string]*BrokenJvm{javaPath: {
+		Error:       "old failure",
+		FailedAt:    time.Now(),
+		Fingerprint: computeFingerprint(javaPath, "test-resources/jdk-17"),
+	}}}
+	content, _ := json.Marshal(cached)
+	test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(path, content, 0644))
+	jvms := LoadJvmsInfos(reader, path, "", CacheEnabled, javaPaths)
+	description := "LoadJvmsInfos() with broken JVMs cached by findjava 1.0.0"
+	test.AssertNoError(t, description, jvms.CheckUsableJvms())
+	test.AssertEquals(t, description+".JavaVendor", "Eclipse Adoptium", jvms.Jvms[javaPath].JavaVendor)
+	test.AssertEquals(t, description+".BrokenJvms", 0, len(LoadCache(path).BrokenJvms))
+}
+
+func TestLoadJvmsInfosRefetchesIncompleteJvms(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	path := filepath.Join(directory, "findjava.json")
+	javaPath := "test-resources/jdk-17/bin/java"
+	javaPaths := &JavaExecutables{JavaPaths: map[string]time.Time{javaPath: time.Unix(0, 0)}}
+	jvms := LoadJvmsInfos(&MetadataReader{Version: "2.0.0"}, path, "", CacheEnabled, javaPaths)
+	test.AssertEquals(t, "LoadJvmsInfos().Strategy", ReleaseFileStrategy, jvms.Jvms[javaPath].Strategy)
+	reader := &MetadataReader{Version: "2.0.0", ReferencedProperties: []string{"java.vm.name"}}
+	jvms = LoadJvmsInfos(reader, path, "", CacheEnabled, javaPaths)
+	// The java executable of the test resources cannot be launched, so the refetched JVM is reported as broken
+	description := "LoadJvmsInfos() referencing java.vm.name"
+	test.AssertErrorContains(t, description, "release files do not provide the properties [java.vm.name]",
+		jvms.CheckUsableJvms())
+}
+
+func TestLoadJvmsInfosConcurrently(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	path := filepath.Join(directory, "cache", "findjava.json")
+	// Slow broken JVMs, so that both runs would load the cache before any of them saves it without lock
+	var javaPaths []string
+	for _, name := range []string{"slow-jdk-1", "slow-jdk-2"} {
+		javaPath := filepath.Join(directory, name, "bin", "java")
+		test.AssertNoError(t, "os.MkdirAll()", os.MkdirAll(filepath.Dir(javaPath), 0755))
+		test.AssertNoError(t, "ioutil.WriteFile()",
+			ioutil.WriteFile(javaPath, []byte("#!/bin/sh\nsleep 0.2\nexit 1\n"), 0755))
+		javaPaths = append(javaPaths, javaPath)
+	}
+	done := make(chan bool)
+	for _, javaPath := range javaPaths {
+		go func(javaPath string) {
+			javaExecutables := &JavaExecutables{JavaPaths: map[string]time.Time{javaPath: time.Unix(0, 0)}}
+			LoadJvmsInfos(&MetadataReader{Version: "2.0.0", CacheDir: directory}, path, "", CacheEnabled,
+				javaExecutables)
+			done <- true
+		}(javaPath)
+	}
+	<-done
+	<-done
+	broken := LoadCache(path).BrokenJvms
+	for _, javaPath := range javaPaths {
+		if _, found := broken[javaPath]; !found {
+			t.Fatalf("Expecting the cache to record the broken JVM %s but got %v", javaPath, broken)
+		}
+	}
+}
+
+func TestLoadJvmsInfosUsesSystemCache(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	systemPath := filepath.Join(directory, "system.json")
+	javaPath := "test-resources/jdk-17/bin/java"
+	javaPaths := &JavaExecutables{JavaPaths: map[string]time.Time{javaPath: time.Unix(0, 0)}}
+	reader := &MetadataReader{Version: "2.0.0"}
+	type TestData struct {
+		systemVersion  string
+		cachePolicy    CachePolicy
+		expectedVendor string
+	}
+	data := []TestData{
+		{systemVersion: "2.0.0", cachePolicy: CacheEnabled, expectedVendor: "System Vendor"},
+		{systemVersion: "2.0.0", cachePolicy: CacheRefresh, expectedVendor: "Eclipse Adoptium"},
+		{systemVersion: "2.0.0", cachePolicy: CacheDisabled, expectedVendor: "Eclipse Adoptium"},
+		{systemVersion: "1.0.0", cachePolicy: CacheEnabled, expectedVendor: "Eclipse Adoptium"},
+	}
+	for i, data := range data {
+		header := reader.cacheHeader()
+		header.FindjavaVersion = data.systemVersion
+		system := JvmsInfos{Header: header, Jvms: map[string]*Jvm{javaPath: {
+			FetchedAt:   time.Now(),
+			Fingerprint: computeFingerprint(javaPath, "test-resources/jdk-17"),
+			SystemProperties: map[string]string{
+				"java.home":                  "test-resources/jdk-17",
+				"java.specification.version": "17",
+				"java.vendor":                "System Vendor",
+			},
+		}}}
+		content, _ := json.Marshal(system)
+		test.AssertNoError(t, "ioutil.WriteFile()", ioutil.WriteFile(systemPath, content, 0644))
+		path := filepath.Join(directory, fmt.Sprintf("findjava-%d.json", i))
+		jvms := LoadJvmsInfos(reader, path, systemPath, data.cachePolicy, javaPaths)
+		err := jvms.CheckUsableJvms()
+		description := fmt.Sprintf("LoadJvmsInfos() with policy %d and a system cache written by findjava %s",
+			data.cachePolicy, data.systemVersion)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".JavaVendor", data.expectedVendor, jvms.Jvms[javaPath].JavaVendor)
+		written, err := ioutil.ReadFile(systemPath)
+		test.AssertNoError(t, "ioutil.ReadFile()", err)
+		test.AssertEquals(t, description+" system cache", string(content), string(written))
+	}
+}
@@ -3,6 +3,7 @@ package jvm
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 const AllVersions = 0
@@ -66,3 +67,105 @@ func ParseJavaSpecificationVersion(version string) (uint, error) {
 	}
 	return javaSpecificationVersion, nil
 }
+
+// JavaVersion is the full version of a JVM as described by JEP 223 and JEP 322,
+// legacy versions such as 1.8.0_392-b08 being mapped to feature 8 and update 392.
+type JavaVersion struct {
+	Feature uint
+	Interim uint
+	Update  uint
+	Patch   uint
+	Build   uint
+}
+
+func (version JavaVersion) String() string {
+	result := fmt.Sprintf("%d.%d.%d", version.Feature, version.Interim, version.Update)
+	if version.Patch != 0 {
+		result += fmt.Sprintf(".%d", version.Patch)
+	}
+	if version.Build != 0 {
+		result += fmt.Sprintf("+%d", version.Build)
+	}
+	return result
+}
+
+// Compare returns a negative number if version is lower than other, zero if they are equal
+// and a positive number if version is greater than other.
+func (version JavaVersion) Compare(other JavaVersion) int {
+	for _, pair := range [][2]uint{
+		{version.Feature, other.Feature},
+		{version.Interim, other.Interim},
+		{version.Update, other.Update},
+		{version.Patch, other.Patch},
+		{version.Build, other.Build},
+	} {
+		if pair[0] < pair[1] {
+			return -1
+		} else if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// ParseJavaVersion parses the value of the java.version or java.runtime.version system properties,
+// for example 17.0.9, 17.0.9+9-LTS, 21+35, 22-ea or 1.8.0_392-b08.
+// A trailing '+' without build number is accepted so that 17.0.9+ can be used to express a minimum version.
+func ParseJavaVersion(version string) (JavaVersion, error) {
+	var result JavaVersion
+	invalid := fmt.Errorf("Java version '%s' cannot be parsed", version)
+	value := strings.TrimSpace(version)
+	if i := strings.Index(value, "+"); i >= 0 {
+		result.Build = leadingNumber(value[i+1:])
+		value = value[:i]
+	}
+	if i := strings.Index(value, "-"); i >= 0 {
+		if qualifier := value[i+1:]; strings.HasPrefix(qualifier, "b") {
+			result.Build = leadingNumber(qualifier[1:])
+		}
+		value = value[:i]
+	}
+	var update string
+	if i := strings.Index(value, "_"); i >= 0 {
+		update = value[i+1:]
+		value = value[:i]
+	}
+	parts := strings.Split(value, ".")
+	if len(parts) > 4 {
+		return JavaVersion{}, invalid
+	}
+	numbers := make([]uint, len(parts))
+	for i, part := range parts {
+		number, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return JavaVersion{}, invalid
+		}
+		numbers[i] = uint(number)
+	}
+	if numbers[0] == 1 && len(numbers) > 1 {
+		// Legacy versions: 1.<feature>.0_<update>
+		result.Feature = numbers[1]
+	} else {
+		fields := []*uint{&result.Feature, &result.Interim, &result.Update, &result.Patch}
+		for i, number := range numbers {
+			*fields[i] = number
+		}
+	}
+	if update != "" {
+		number, err := strconv.ParseUint(update, 10, 32)
+		if err != nil {
+			return JavaVersion{}, invalid
+		}
+		result.Update = uint(number)
+	}
+	return result, nil
+}
+
+func leadingNumber(value string) uint {
+	end := 0
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	number, _ := strconv.ParseUint(value[:end], 10, 32)
+	return uint(number)
+}
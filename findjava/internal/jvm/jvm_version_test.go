@@ -42,3 +42,47 @@ func TestParseVersionError(t *testing.T) {
 		test.AssertErrorContains(t, description, expected, err)
 	}
 }
+
+func TestParseJavaVersion(t *testing.T) {
+	versions := map[string]JavaVersion{
+		"17":            {Feature: 17},
+		"17.0.9":        {Feature: 17, Update: 9},
+		"17.0.9+":       {Feature: 17, Update: 9},
+		"17.0.9+9-LTS":  {Feature: 17, Update: 9, Build: 9},
+		"17.0.9.1":      {Feature: 17, Update: 9, Patch: 1},
+		"21+35":         {Feature: 21, Build: 35},
+		"22-ea":         {Feature: 22},
+		"1.8.0_392-b08": {Feature: 8, Update: 392, Build: 8},
+		"1.8.0_392":     {Feature: 8, Update: 392},
+	}
+	for versionToParse, expected := range versions {
+		actual, err := ParseJavaVersion(versionToParse)
+		description := fmt.Sprintf("ParseJavaVersion(%s)", versionToParse)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description, expected, actual)
+	}
+}
+
+func TestParseJavaVersionError(t *testing.T) {
+	for _, versionToParse := range []string{"", "one", "17.0.x", "17.0.9.1.2", "1.8.0_x"} {
+		_, err := ParseJavaVersion(versionToParse)
+		description := fmt.Sprintf("ParseJavaVersion(%s)", versionToParse)
+		test.AssertErrorContains(t, description, fmt.Sprintf("Java version '%s' cannot be parsed", versionToParse), err)
+	}
+}
+
+func TestJavaVersionCompare(t *testing.T) {
+	ordered := []JavaVersion{
+		{Feature: 11, Update: 21},
+		{Feature: 17},
+		{Feature: 17, Update: 9},
+		{Feature: 17, Update: 9, Build: 9},
+		{Feature: 17, Update: 9, Patch: 1},
+		{Feature: 17, Update: 10},
+	}
+	for i := 1; i < len(ordered); i++ {
+		description := fmt.Sprintf("%s.Compare(%s)", ordered[i-1], ordered[i])
+		test.AssertEquals(t, description, -1, ordered[i-1].Compare(ordered[i]))
+		test.AssertEquals(t, description, 1, ordered[i].Compare(ordered[i-1]))
+	}
+}
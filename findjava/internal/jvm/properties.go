@@ -0,0 +1,122 @@
+package jvm
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultExtractedProperties are the patterns of the system properties extracted from the JVMs by default.
+// A pattern ending with * matches every property starting with the pattern without the *.
+var DefaultExtractedProperties = []string{"java.*", "os.arch", "sun.arch.data.model"}
+
+// requiredProperties are always extracted as findjava relies on them.
+var requiredProperties = []string{
+	"java.home",
+	"java.runtime.version",
+	"java.specification.version",
+	"java.vendor",
+	"java.version",
+	"os.arch",
+	"sun.arch.data.model",
+}
+
+// releaseFileProperties are the system properties provided by the release files, see readReleaseFile.
+var releaseFileProperties = []string{
+	"java.home",
+	"java.runtime.version",
+	"java.specification.version",
+	"java.vendor",
+	"java.vendor.version",
+	"java.version",
+	"os.arch",
+	"sun.arch.data.model",
+}
+
+// extractedProperties returns the sorted patterns of the system properties to extract, completed with the
+// required properties they do not match.
+func (f *MetadataReader) extractedProperties() []string {
+	configured := f.Properties
+	if len(configured) == 0 {
+		configured = DefaultExtractedProperties
+	}
+	patterns := append([]string{}, configured...)
+	for _, property := range requiredProperties {
+		if !matchesPropertyPatterns(configured, property) {
+			patterns = append(patterns, property)
+		}
+	}
+	sort.Strings(patterns)
+	deduplicated := patterns[:0]
+	for i, pattern := range patterns {
+		if i == 0 || pattern != patterns[i-1] {
+			deduplicated = append(deduplicated, pattern)
+		}
+	}
+	return deduplicated
+}
+
+// extractsDefaultProperties returns true when the extracted properties are the default or required ones,
+// which the release files provide.
+func (f *MetadataReader) extractsDefaultProperties() bool {
+	for _, pattern := range f.extractedProperties() {
+		if !contains(DefaultExtractedProperties, pattern) && !contains(requiredProperties, pattern) {
+			return false
+		}
+	}
+	return true
+}
+
+// missingReleaseFileProperties returns the referenced properties which the release files do not provide.
+func (f *MetadataReader) missingReleaseFileProperties() []string {
+	var missing []string
+	for _, property := range f.ReferencedProperties {
+		if !contains(releaseFileProperties, property) {
+			missing = append(missing, property)
+		}
+	}
+	return missing
+}
+
+// providesReferencedProperties returns true when the metadata of the JVM provide every referenced property
+// the rules may need, which is only false for the JVMs whose release file lacks some of them.
+func (f *MetadataReader) providesReferencedProperties(jvm *Jvm) bool {
+	return jvm.Strategy != ReleaseFileStrategy || len(f.missingReleaseFileProperties()) == 0
+}
+
+// UnextractedProperties returns the properties which are not extracted from the JVMs with the given patterns,
+// DefaultExtractedProperties when empty. The "*" computed key of filter expressions is ignored.
+func UnextractedProperties(patterns []string, properties []string) []string {
+	extracted := (&MetadataReader{Properties: patterns}).extractedProperties()
+	var unextracted []string
+	for _, property := range properties {
+		if property != "*" && !matchesPropertyPatterns(extracted, property) {
+			unextracted = append(unextracted, property)
+		}
+	}
+	return unextracted
+}
+
+func matchesPropertyPatterns(patterns []string, property string) bool {
+	for _, pattern := range patterns {
+		if matchesPropertyPattern(pattern, property) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesPropertyPattern(pattern string, property string) bool {
+	if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+		return strings.HasPrefix(property, prefix)
+	}
+	return pattern == property
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
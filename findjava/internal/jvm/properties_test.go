@@ -0,0 +1,52 @@
+package jvm
+
+import (
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestExtractedProperties(t *testing.T) {
+	data := []struct {
+		configured      []string
+		expected        []string
+		extractsDefault bool
+	}{
+		{nil, []string{"java.*", "os.arch", "sun.arch.data.model"}, true},
+		{[]string{"java.*", "jdk.debug"}, []string{"java.*", "jdk.debug", "os.arch", "sun.arch.data.model"}, false},
+		{[]string{"java.version", "java.version"}, []string{
+			"java.home", "java.runtime.version", "java.specification.version", "java.vendor", "java.version",
+			"os.arch", "sun.arch.data.model",
+		}, true},
+		{[]string{"os.*"}, []string{
+			"java.home", "java.runtime.version", "java.specification.version", "java.vendor", "java.version", "os.*",
+			"sun.arch.data.model",
+		}, false},
+	}
+	for _, d := range data {
+		reader := &MetadataReader{Properties: d.configured}
+		description := fmt.Sprintf("MetadataReader{Properties: %v}", d.configured)
+		test.AssertEquals(t, description+".extractedProperties()", d.expected, reader.extractedProperties())
+		test.AssertEquals(t, description+".extractsDefaultProperties()", d.extractsDefault,
+			reader.extractsDefaultProperties())
+	}
+}
+
+func TestCacheHeaderDependsOnExtractedProperties(t *testing.T) {
+	defaultHeader := (&MetadataReader{}).cacheHeader()
+	test.AssertEquals(t, "cacheHeader() with the default properties", defaultHeader,
+		(&MetadataReader{Properties: DefaultExtractedProperties}).cacheHeader())
+	header := (&MetadataReader{Properties: []string{"java.*", "jdk.debug"}}).cacheHeader()
+	test.AssertEquals(t, "cacheHeader().Properties", "java.*,jdk.debug,os.arch,sun.arch.data.model", header.Properties)
+	test.AssertEquals(t, "cacheHeader() with other properties equals the default one", false, header == defaultHeader)
+}
+
+func TestUnextractedProperties(t *testing.T) {
+	properties := []string{"java.vm.name", "jdk.debug", "os.arch", "*"}
+	test.AssertEquals(t, "UnextractedProperties() with the default properties", []string{"jdk.debug"},
+		UnextractedProperties(nil, properties))
+	test.AssertEquals(t, "UnextractedProperties() with jdk.debug", []string(nil),
+		UnextractedProperties([]string{"java.*", "jdk.debug"}, properties))
+	test.AssertEquals(t, "UnextractedProperties() with os.*", []string{"java.vm.name", "jdk.debug"},
+		UnextractedProperties([]string{"os.*"}, properties))
+}
@@ -0,0 +1,84 @@
+package jvm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PropertyOperator is the comparison a PropertyConstraint applies to the value of a system property.
+type PropertyOperator string
+
+const (
+	// PropertyEquals requires the property to be defined and equal to the value.
+	PropertyEquals PropertyOperator = "="
+	// PropertyNotEquals requires the property to be undefined or different from the value.
+	PropertyNotEquals PropertyOperator = "!="
+	// PropertyMatches requires the property to be defined and to contain a match of the regular expression.
+	PropertyMatches PropertyOperator = "~="
+	// PropertyNotMatches requires the property to be undefined or to contain no match of the regular expression.
+	PropertyNotMatches PropertyOperator = "!~="
+)
+
+// PropertyConstraint is a constraint on a system property of the JVMs, parsed from a textual constraint such as
+// "java.vm.name=OpenJDK 64-Bit Server VM", "java.vm.name~=OpenJ9", "java.vendor!=Oracle Corporation"
+// or "java.vm.name!~=Zero".
+type PropertyConstraint struct {
+	Key      string
+	Operator PropertyOperator
+	Value    string
+	regexp   *regexp.Regexp
+}
+
+// ParsePropertyConstraint parses a property constraint. See PropertyConstraint for the syntax.
+func ParsePropertyConstraint(constraint string) (*PropertyConstraint, error) {
+	index := strings.Index(constraint, "=")
+	if index < 0 {
+		return nil, fmt.Errorf("invalid property constraint \"%s\": expecting KEY=VALUE, KEY!=VALUE, "+
+			"KEY~=REGEX or KEY!~=REGEX", constraint)
+	}
+	key := constraint[:index]
+	operator := PropertyEquals
+	if strings.HasSuffix(key, "!~") {
+		operator = PropertyNotMatches
+	} else if strings.HasSuffix(key, "~") {
+		operator = PropertyMatches
+	} else if strings.HasSuffix(key, "!") {
+		operator = PropertyNotEquals
+	}
+	parsed := &PropertyConstraint{
+		Key:      strings.TrimSpace(key[:len(key)-len(operator)+1]),
+		Operator: operator,
+		Value:    strings.TrimSpace(constraint[index+1:]),
+	}
+	if parsed.Key == "" {
+		return nil, fmt.Errorf("invalid property constraint \"%s\": property name is empty", constraint)
+	}
+	if operator == PropertyMatches || operator == PropertyNotMatches {
+		compiled, err := regexp.Compile(parsed.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid property constraint \"%s\": %v", constraint, err)
+		}
+		parsed.regexp = compiled
+	}
+	return parsed, nil
+}
+
+// Matches returns true if the system properties satisfy the constraint.
+func (constraint *PropertyConstraint) Matches(properties map[string]string) bool {
+	value, defined := properties[constraint.Key]
+	switch constraint.Operator {
+	case PropertyNotEquals:
+		return !defined || value != constraint.Value
+	case PropertyMatches:
+		return defined && constraint.regexp.MatchString(value)
+	case PropertyNotMatches:
+		return !defined || !constraint.regexp.MatchString(value)
+	default:
+		return defined && value == constraint.Value
+	}
+}
+
+func (constraint *PropertyConstraint) String() string {
+	return constraint.Key + string(constraint.Operator) + constraint.Value
+}
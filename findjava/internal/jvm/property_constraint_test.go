@@ -0,0 +1,68 @@
+package jvm
+
+import (
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestParsePropertyConstraint(t *testing.T) {
+	data := map[string]PropertyConstraint{
+		"java.vm.name=OpenJDK 64-Bit Server VM": {Key: "java.vm.name", Operator: PropertyEquals, Value: "OpenJDK 64-Bit Server VM"},
+		"java.vendor!=Oracle Corporation":       {Key: "java.vendor", Operator: PropertyNotEquals, Value: "Oracle Corporation"},
+		"java.vm.name~=OpenJ9":                  {Key: "java.vm.name", Operator: PropertyMatches, Value: "OpenJ9"},
+		"java.vm.name!~=Zero":                   {Key: "java.vm.name", Operator: PropertyNotMatches, Value: "Zero"},
+		" jdk.debug = release ":                 {Key: "jdk.debug", Operator: PropertyEquals, Value: "release"},
+		"jdk.debug=":                            {Key: "jdk.debug", Operator: PropertyEquals, Value: ""},
+		"java.vm.info=mode=mixed":               {Key: "java.vm.info", Operator: PropertyEquals, Value: "mode=mixed"},
+	}
+	for constraint, expected := range data {
+		parsed, err := ParsePropertyConstraint(constraint)
+		description := fmt.Sprintf("ParsePropertyConstraint(\"%s\")", constraint)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".Key", expected.Key, parsed.Key)
+		test.AssertEquals(t, description+".Operator", expected.Operator, parsed.Operator)
+		test.AssertEquals(t, description+".Value", expected.Value, parsed.Value)
+	}
+}
+
+func TestParseInvalidPropertyConstraint(t *testing.T) {
+	data := map[string]string{
+		"java.vm.name":    "invalid property constraint \"java.vm.name\": expecting KEY=VALUE, KEY!=VALUE, KEY~=REGEX or KEY!~=REGEX",
+		"=OpenJ9":         "invalid property constraint \"=OpenJ9\": property name is empty",
+		" ~=OpenJ9":       "invalid property constraint \" ~=OpenJ9\": property name is empty",
+		"java.vm.name~=(": "invalid property constraint \"java.vm.name~=(\": error parsing regexp: missing closing ): `(`",
+	}
+	for constraint, expectedError := range data {
+		_, err := ParsePropertyConstraint(constraint)
+		test.AssertErrorContains(t, fmt.Sprintf("ParsePropertyConstraint(\"%s\")", constraint), expectedError, err)
+	}
+}
+
+func TestPropertyConstraintMatches(t *testing.T) {
+	properties := map[string]string{
+		"java.vm.name": "Eclipse OpenJ9 VM",
+		"java.vendor":  "IBM Corporation",
+	}
+	data := map[string]bool{
+		"java.vm.name=Eclipse OpenJ9 VM": true,
+		"java.vm.name=OpenJ9":            false,
+		"jdk.debug=":                     false,
+		"java.vendor!=IBM Corporation":   false,
+		"java.vendor!=Oracle":            true,
+		"jdk.debug!=release":             true,
+		"java.vm.name~=OpenJ9":           true,
+		"java.vm.name~=^OpenJ9":          false,
+		"jdk.debug~=.*":                  false,
+		"java.vm.name!~=Zero":            true,
+		"java.vm.name!~=J9":              false,
+		"jdk.debug!~=.*":                 true,
+	}
+	for constraint, expected := range data {
+		parsed, err := ParsePropertyConstraint(constraint)
+		description := fmt.Sprintf("ParsePropertyConstraint(\"%s\")", constraint)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".Matches()", expected, parsed.Matches(properties))
+		test.AssertEquals(t, description+".String()", constraint, parsed.String())
+	}
+}
@@ -0,0 +1,95 @@
+package jvm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// extractorProtocolVersion is the version of the output format of the metadata extractor class supported by findjava.
+// It must be kept in sync with JvmMetadataExtractor.PROTOCOL_VERSION.
+const extractorProtocolVersion = 1
+
+// The metadata extractor class prints its JSON document between those lines, anything else printed by the JVM
+// being ignored.
+const (
+	extractorBeginMarker = "--- findjava metadata begin ---"
+	extractorEndMarker   = "--- findjava metadata end ---"
+)
+
+// maxDiagnosticOutput is the maximum length of the extractor output quoted in the parse errors.
+const maxDiagnosticOutput = 512
+
+// extractorDocument is the JSON document printed by the metadata extractor class.
+type extractorDocument struct {
+	ProtocolVersion *int              `json:"protocolVersion"`
+	Properties      map[string]string `json:"properties"`
+}
+
+// parseExtractorOutput returns the system properties printed by the metadata extractor class. The output is strictly
+// validated: it must contain exactly one document between the markers, in the supported protocol version.
+func parseExtractorOutput(output string) (map[string]string, error) {
+	lines := strings.Split(output, "\n")
+	begin, end := -1, -1
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if line == extractorBeginMarker {
+			if begin >= 0 {
+				return nil, protocolError(output, "%q is printed more than once", extractorBeginMarker)
+			}
+			begin = i
+		} else if line == extractorEndMarker && begin >= 0 && end < 0 {
+			end = i
+		}
+	}
+	if begin < 0 {
+		return nil, protocolError(output, "%q not found, the metadata extractor class may be outdated",
+			extractorBeginMarker)
+	}
+	if end < 0 {
+		return nil, protocolError(output, "%q not found, the output is truncated", extractorEndMarker)
+	}
+	payload := strings.Join(lines[begin+1:end], "\n")
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	var document extractorDocument
+	if err := decoder.Decode(&document); err != nil {
+		return nil, protocolError(payload, "invalid metadata document: %v", describeJsonError(payload, err))
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, protocolError(payload, "unexpected content after the metadata document")
+	}
+	if document.ProtocolVersion == nil {
+		return nil, protocolError(payload, "the metadata document has no protocolVersion")
+	}
+	if *document.ProtocolVersion != extractorProtocolVersion {
+		return nil, protocolError(payload, "unsupported protocol version %d, findjava supports version %d",
+			*document.ProtocolVersion, extractorProtocolVersion)
+	}
+	if document.Properties == nil {
+		return nil, protocolError(payload, "the metadata document has no properties")
+	}
+	return document.Properties, nil
+}
+
+// describeJsonError adds the line and column of syntax errors to their message.
+func describeJsonError(payload string, err error) string {
+	var syntaxError *json.SyntaxError
+	if errors.As(err, &syntaxError) {
+		read := payload[:syntaxError.Offset]
+		line := strings.Count(read, "\n") + 1
+		column := len(read) - strings.LastIndex(read, "\n") - 1
+		return fmt.Sprintf("%v at line %d, column %d", err, line, column)
+	}
+	return err.Error()
+}
+
+func protocolError(output string, format string, v ...interface{}) error {
+	if len(output) > maxDiagnosticOutput {
+		output = output[:maxDiagnosticOutput] + "..."
+	}
+	return fmt.Errorf("unable to parse the metadata extractor output: %s\n\toutput was: %q",
+		fmt.Sprintf(format, v...), output)
+}
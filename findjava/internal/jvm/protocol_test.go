@@ -0,0 +1,79 @@
+package jvm
+
+import (
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestParseExtractorOutput(t *testing.T) {
+	type TestData struct {
+		output   string
+		expected map[string]string
+	}
+	data := []TestData{{
+		output: "\n--- findjava metadata begin ---\n" +
+			`{"protocolVersion":1,"properties":{"java.home":"/usr/lib/jvm/java-17","os.arch":"amd64"}}` +
+			"\n--- findjava metadata end ---\n",
+		expected: map[string]string{"java.home": "/usr/lib/jvm/java-17", "os.arch": "amd64"},
+	}, {
+		output: "OpenJDK 64-Bit Server VM warning: Options -Xverify:none are deprecated\r\n" +
+			"--- findjava metadata begin ---\r\n" +
+			`{"protocolVersion":1,"properties":{"java.vendor":"Multi\nline \"vendor\" é"}}` + "\r\n" +
+			"--- findjava metadata end ---\r\n" +
+			"java.home=/wrong\n",
+		expected: map[string]string{"java.vendor": "Multi\nline \"vendor\" é"},
+	}}
+	for _, data := range data {
+		actual, err := parseExtractorOutput(data.output)
+		description := fmt.Sprintf("parseExtractorOutput(%q)", data.output)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description, data.expected, actual)
+	}
+}
+
+func TestParseExtractorOutputErrors(t *testing.T) {
+	type TestData struct {
+		output string
+		err    string
+	}
+	document := func(payload string) string {
+		return "--- findjava metadata begin ---\n" + payload + "\n--- findjava metadata end ---\n"
+	}
+	data := []TestData{{
+		output: "java.home=/usr/lib/jvm/java-17\n",
+		err:    `"--- findjava metadata begin ---" not found, the metadata extractor class may be outdated`,
+	}, {
+		output: "--- findjava metadata begin ---\n{\"protocolVersion\":1,",
+		err:    `"--- findjava metadata end ---" not found, the output is truncated`,
+	}, {
+		output: document(`{}`) + document(`{}`),
+		err:    `"--- findjava metadata begin ---" is printed more than once`,
+	}, {
+		output: document("{\"protocolVersion\":1,\n\"properties\":{\"java.home\" \"/jvm\"}}"),
+		err:    "invalid metadata document: invalid character '\"' after object key at line 2, column 27",
+	}, {
+		output: document(`{"protocolVersion":1,"properties":{"java.home":1}}`),
+		err:    "invalid metadata document: json: cannot unmarshal number into Go struct field",
+	}, {
+		output: document(`{"protocolVersion":1,"properties":{},"extra":true}`),
+		err:    `invalid metadata document: json: unknown field "extra"`,
+	}, {
+		output: document(`{"protocolVersion":1,"properties":{}} {}`),
+		err:    "unexpected content after the metadata document",
+	}, {
+		output: document(`{"properties":{}}`),
+		err:    "the metadata document has no protocolVersion",
+	}, {
+		output: document(`{"protocolVersion":2,"properties":{}}`),
+		err:    "unsupported protocol version 2, findjava supports version 1",
+	}, {
+		output: document(`{"protocolVersion":1}`),
+		err:    "the metadata document has no properties",
+	}}
+	for _, data := range data {
+		_, err := parseExtractorOutput(data.output)
+		description := fmt.Sprintf("parseExtractorOutput(%q)", data.output)
+		test.AssertErrorContains(t, description, data.err, err)
+	}
+}
@@ -0,0 +1,102 @@
+package jvm
+
+import (
+	"bufio"
+	"findjava/internal/log"
+	"findjava/internal/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// releaseFileName is the name of the file describing a JDK in its java.home directory.
+const releaseFileName = "release"
+
+// releaseProperties maps the keys of the release file to the system properties they provide.
+var releaseProperties = map[string]string{
+	"JAVA_VERSION":         "java.version",
+	"JAVA_RUNTIME_VERSION": "java.runtime.version",
+	"IMPLEMENTOR":          "java.vendor",
+	"IMPLEMENTOR_VERSION":  "java.vendor.version",
+	"OS_ARCH":              "os.arch",
+}
+
+// readReleaseFile builds the JVM metadata from the release file of the JDK the java executable belongs to,
+// which is much faster than launching the JVM.
+// An error is returned when the release file does not exist or lacks required properties, in which case
+// the metadata must be extracted by launching the JVM.
+func readReleaseFile(javaPath string) (*Jvm, error) {
+	javaHome := filepath.Dir(filepath.Dir(javaPath))
+	releasePath := filepath.Join(javaHome, releaseFileName)
+	release, err := parseReleaseFile(releasePath)
+	if err != nil {
+		return nil, err
+	}
+	systemProperties := map[string]string{"java.home": javaHome}
+	for key, property := range releaseProperties {
+		if value, found := release[key]; found && value != "" {
+			systemProperties[property] = value
+		}
+	}
+	for _, property := range []string{"java.version", "java.vendor", "os.arch"} {
+		if _, found := systemProperties[property]; !found {
+			return nil, fmt.Errorf("release file %s does not define %s", releasePath, property)
+		}
+	}
+	version, err := ParseJavaVersion(systemProperties["java.version"])
+	if err != nil {
+		return nil, log.WrapErr(err, "invalid JAVA_VERSION in release file %s", releasePath)
+	}
+	// Up to Java 8, java.home is the jre directory of the JDK, which the release file cannot tell
+	if strings.HasPrefix(systemProperties["java.version"], "1.") {
+		return nil, fmt.Errorf("release file %s describes a legacy JVM", releasePath)
+	}
+	systemProperties["java.specification.version"] = strconv.Itoa(int(version.Feature))
+	// The JVMs report os.arch with their own names, such as amd64 where the release file says x86_64
+	systemProperties["os.arch"] = NormalizeArch(systemProperties["os.arch"])
+	if dataModel := archDataModel(systemProperties["os.arch"]); dataModel != 0 {
+		systemProperties["sun.arch.data.model"] = strconv.Itoa(int(dataModel))
+	}
+	jvm := Jvm{
+		javaPath:         javaPath,
+		FetchedAt:        time.Now(),
+		SystemProperties: systemProperties,
+	}
+	if err := jvm.rebuild(); err != nil {
+		return nil, log.WrapErr(err, "invalid release file %s", releasePath)
+	}
+	return &jvm, nil
+}
+
+// parseReleaseFile parses the KEY="value" lines of a release file.
+func parseReleaseFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseFile(file)
+	release := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+			value = value[1 : len(value)-1]
+		}
+		release[strings.TrimSpace(parts[0])] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
@@ -0,0 +1,40 @@
+package jvm
+
+import (
+	"findjava/test"
+	"testing"
+)
+
+func TestReadReleaseFile(t *testing.T) {
+	jvm, err := readReleaseFile("test-resources/jdk-17/bin/java")
+	description := "readReleaseFile(\"test-resources/jdk-17/bin/java\")"
+	test.AssertNoError(t, description, err)
+	test.AssertEquals(t, description+".JavaHome", "test-resources/jdk-17", jvm.JavaHome)
+	test.AssertEquals(t, description+".JavaSpecificationVersion", uint(17), jvm.JavaSpecificationVersion)
+	test.AssertEquals(t, description+".JavaVersion", JavaVersion{Feature: 17, Update: 9, Build: 9}, jvm.JavaVersion)
+	test.AssertEquals(t, description+".JavaVendor", "Eclipse Adoptium", jvm.JavaVendor)
+	test.AssertEquals(t, description+".OsArch", "amd64", jvm.OsArch)
+	test.AssertEquals(t, description+".DataModel", uint(64), jvm.DataModel)
+	test.AssertEquals(t, description+".SystemProperties", map[string]string{
+		"java.home":                  "test-resources/jdk-17",
+		"java.specification.version": "17",
+		"java.version":               "17.0.9",
+		"java.runtime.version":       "17.0.9+9",
+		"java.vendor":                "Eclipse Adoptium",
+		"java.vendor.version":        "Temurin-17.0.9+9",
+		"os.arch":                    "amd64",
+		"sun.arch.data.model":        "64",
+	}, jvm.SystemProperties)
+}
+
+func TestReadReleaseFileErrors(t *testing.T) {
+	data := map[string]string{
+		"test-resources/missing-jdk/bin/java":    "no such file or directory",
+		"test-resources/incomplete-jdk/bin/java": "release file test-resources/incomplete-jdk/release does not define java.vendor",
+		"test-resources/jdk-8/bin/java":          "release file test-resources/jdk-8/release describes a legacy JVM",
+	}
+	for javaPath, expected := range data {
+		_, err := readReleaseFile(javaPath)
+		test.AssertErrorContains(t, "readReleaseFile(\""+javaPath+"\")", expected, err)
+	}
+}
@@ -0,0 +1,169 @@
+package jvm
+
+import (
+	"findjava/internal/log"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MetadataStrategy identifies how the metadata of a JVM have been fetched.
+type MetadataStrategy string
+
+const (
+	// ReleaseFileStrategy reads the release file of the JVM without launching it.
+	ReleaseFileStrategy MetadataStrategy = "release-file"
+	// ExtractorStrategy launches the JVM with the metadata extractor class, which requires Java 8 or above.
+	ExtractorStrategy MetadataStrategy = "extractor"
+	// ShowSettingsStrategy parses the system properties printed by java -XshowSettings:properties -version,
+	// which requires Java 7 or above.
+	ShowSettingsStrategy MetadataStrategy = "show-settings"
+	// VersionBannerStrategy parses the banner printed by java -version, which only provides the version of the JVM
+	// and possibly its data model.
+	VersionBannerStrategy MetadataStrategy = "version-banner"
+)
+
+type metadataStrategy struct {
+	name  MetadataStrategy
+	fetch func(f *MetadataReader, javaPath string) (*Jvm, error)
+}
+
+// metadataStrategies are the metadata strategies by order of preference, from the fastest and most complete
+// to the most compatible one.
+var metadataStrategies = []metadataStrategy{
+	{ReleaseFileStrategy, (*MetadataReader).releaseFileJvmInfo},
+	{ExtractorStrategy, (*MetadataReader).extractJvmInfo},
+	{ShowSettingsStrategy, (*MetadataReader).showSettingsJvmInfo},
+	{VersionBannerStrategy, (*MetadataReader).versionBannerJvmInfo},
+}
+
+func (f *MetadataReader) releaseFileJvmInfo(javaPath string) (*Jvm, error) {
+	if !f.extractsDefaultProperties() {
+		return nil, fmt.Errorf("release files do not provide the configured properties %v", f.Properties)
+	}
+	if missing := f.missingReleaseFileProperties(); len(missing) > 0 {
+		return nil, fmt.Errorf("release files do not provide the properties %v used by the JVM selection rules",
+			missing)
+	}
+	return readReleaseFile(javaPath)
+}
+
+func (f *MetadataReader) extractJvmInfo(javaPath string) (*Jvm, error) {
+	classpath, err := f.extractorClasspath()
+	if err != nil {
+		return nil, err
+	}
+	args := append(append([]string{}, startupOptions...), "-cp", classpath, MetadataExtractorClass)
+	args = append(args, f.extractedProperties()...)
+	output, _, err := f.run(javaPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	systemProperties, err := parseExtractorOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	return newJvm(javaPath, systemProperties)
+}
+
+func (f *MetadataReader) showSettingsJvmInfo(javaPath string) (*Jvm, error) {
+	args := append(append([]string{}, startupOptions...), "-XshowSettings:properties", "-version")
+	_, output, err := f.run(javaPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	systemProperties := parseShowSettings(output, f.extractedProperties())
+	if _, found := systemProperties["java.home"]; !found {
+		return nil, fmt.Errorf("no system properties printed by %s -XshowSettings:properties", javaPath)
+	}
+	return newJvm(javaPath, systemProperties)
+}
+
+// parseShowSettings parses the "    key = value" lines printed by -XshowSettings:properties, keeping the properties
+// matching the patterns. The values of path lists are printed one element per line, more indented, and are joined back.
+func parseShowSettings(output string, patterns []string) map[string]string {
+	systemProperties := make(map[string]string)
+	var property string
+	for _, line := range strings.Split(output, "\n") {
+		value := strings.TrimSpace(line)
+		if property != "" && strings.HasPrefix(line, "        ") {
+			systemProperties[property] += string(os.PathListSeparator) + value
+			continue
+		}
+		property = ""
+		if !strings.HasPrefix(line, "    ") {
+			continue
+		}
+		split := strings.SplitN(value, "=", 2)
+		if key := strings.TrimSpace(split[0]); len(split) == 2 && matchesPropertyPatterns(patterns, key) {
+			property = key
+			systemProperties[property] = strings.TrimSpace(split[1])
+		}
+	}
+	return systemProperties
+}
+
+// versionPattern matches the first line of the java -version banner, for example: java version "1.7.0_80"
+var versionPattern = regexp.MustCompile(`(?m)^\S+ version "([^"]+)"`)
+
+// runtimePattern matches the second line of the java -version banner,
+// for example: Java(TM) SE Runtime Environment (build 1.7.0_80-b15)
+var runtimePattern = regexp.MustCompile(`(?m)^(.+) \(build ([^)]+)\)\s*$`)
+
+func (f *MetadataReader) versionBannerJvmInfo(javaPath string) (*Jvm, error) {
+	args := append(append([]string{}, startupOptions...), "-version")
+	_, output, err := f.run(javaPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	systemProperties, err := parseVersionBanner(output)
+	if err != nil {
+		return nil, err
+	}
+	// The banner does not tell java.home, which is the jre directory of a JDK up to Java 8
+	systemProperties["java.home"] = filepath.Dir(filepath.Dir(javaPath))
+	return newJvm(javaPath, systemProperties)
+}
+
+// parseVersionBanner parses the system properties which can be deduced from the java -version banner.
+func parseVersionBanner(output string) (map[string]string, error) {
+	match := versionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return nil, fmt.Errorf("no version found in the java -version banner: %s", strings.TrimSpace(output))
+	}
+	version, err := ParseJavaVersion(match[1])
+	if err != nil {
+		return nil, err
+	}
+	specificationVersion := fmt.Sprintf("%d", version.Feature)
+	if version.Feature <= 8 {
+		specificationVersion = "1." + specificationVersion
+	}
+	systemProperties := map[string]string{
+		"java.version":               match[1],
+		"java.specification.version": specificationVersion,
+	}
+	if match := runtimePattern.FindStringSubmatch(output); match != nil {
+		systemProperties["java.runtime.name"] = match[1]
+		systemProperties["java.runtime.version"] = match[2]
+	}
+	if strings.Contains(output, "64-Bit") {
+		systemProperties["sun.arch.data.model"] = "64"
+	}
+	return systemProperties, nil
+}
+
+func newJvm(javaPath string, systemProperties map[string]string) (*Jvm, error) {
+	jvm := Jvm{
+		javaPath:         javaPath,
+		FetchedAt:        time.Now(),
+		SystemProperties: systemProperties,
+	}
+	if err := jvm.rebuild(); err != nil {
+		return nil, log.WrapErr(err, "invalid metadata extracted from %s", javaPath)
+	}
+	return &jvm, nil
+}
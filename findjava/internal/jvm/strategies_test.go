@@ -0,0 +1,138 @@
+package jvm
+
+import (
+	"findjava/test"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFetchJvmInfoStrategies(t *testing.T) {
+	type TestData struct {
+		javaPath             string
+		strategy             MetadataStrategy
+		javaHome             string
+		specificationVersion uint
+		javaVersion          JavaVersion
+		vendor               string
+		osArch               string
+		dataModel            uint
+	}
+	data := []TestData{{
+		javaPath:             "test-resources/jdk-17/bin/java",
+		strategy:             ReleaseFileStrategy,
+		javaHome:             "test-resources/jdk-17",
+		specificationVersion: 17,
+		javaVersion:          JavaVersion{Feature: 17, Update: 9, Build: 9},
+		vendor:               "Eclipse Adoptium",
+		osArch:               "amd64",
+		dataModel:            64,
+	}, {
+		javaPath:             "test-resources/jdk-7/bin/java",
+		strategy:             ShowSettingsStrategy,
+		javaHome:             "/usr/lib/jvm/java-7-openjdk-amd64/jre",
+		specificationVersion: 7,
+		javaVersion:          JavaVersion{Feature: 7, Update: 80, Build: 15},
+		vendor:               "Oracle Corporation",
+		osArch:               "amd64",
+		dataModel:            64,
+	}, {
+		javaPath:             "test-resources/jdk-6/bin/java",
+		strategy:             VersionBannerStrategy,
+		javaHome:             "test-resources/jdk-6",
+		specificationVersion: 6,
+		javaVersion:          JavaVersion{Feature: 6, Update: 45, Build: 6},
+		dataModel:            64,
+	}}
+	for _, data := range data {
+		jvm, err := (&MetadataReader{}).fetchJvmInfo(data.javaPath)
+		description := fmt.Sprintf("fetchJvmInfo(%s)", data.javaPath)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".Strategy", data.strategy, jvm.Strategy)
+		test.AssertEquals(t, description+".JavaHome", data.javaHome, jvm.JavaHome)
+		test.AssertEquals(t, description+".JavaSpecificationVersion", data.specificationVersion,
+			jvm.JavaSpecificationVersion)
+		test.AssertEquals(t, description+".JavaVersion", data.javaVersion, jvm.JavaVersion)
+		test.AssertEquals(t, description+".JavaVendor", data.vendor, jvm.JavaVendor)
+		test.AssertEquals(t, description+".OsArch", data.osArch, jvm.OsArch)
+		test.AssertEquals(t, description+".DataModel", data.dataModel, jvm.DataModel)
+	}
+}
+
+func TestReleaseFileJvmInfoWithReferencedProperties(t *testing.T) {
+	javaPath := "test-resources/jdk-17/bin/java"
+	jvm, err := (&MetadataReader{ReferencedProperties: []string{"java.vendor", "os.arch"}}).releaseFileJvmInfo(javaPath)
+	test.AssertNoError(t, "releaseFileJvmInfo() referencing java.vendor and os.arch", err)
+	test.AssertEquals(t, "releaseFileJvmInfo().SystemProperties[os.arch]", "amd64", jvm.SystemProperties["os.arch"])
+	_, err = (&MetadataReader{ReferencedProperties: []string{"java.vm.name", "os.arch"}}).releaseFileJvmInfo(javaPath)
+	test.AssertErrorContains(t, "releaseFileJvmInfo() referencing java.vm.name",
+		"release files do not provide the properties [java.vm.name]", err)
+}
+
+func TestFetchJvmInfoReportsEveryStrategy(t *testing.T) {
+	_, err := (&MetadataReader{}).fetchJvmInfo("test-resources/missing-jdk/bin/java")
+	test.AssertErrorContains(t, "fetchJvmInfo()", "unable to fetch the metadata of test-resources/missing-jdk/bin/java "+
+		"with any strategy: release-file: open test-resources/missing-jdk/release: no such file or directory; ", err)
+	for _, strategy := range []MetadataStrategy{ExtractorStrategy, ShowSettingsStrategy, VersionBannerStrategy} {
+		test.AssertErrorContains(t, "fetchJvmInfo()", fmt.Sprintf("; %s: ", strategy), err)
+	}
+	if strings.Contains(err.Error(), "\n") {
+		t.Fatalf("Expecting fetchJvmInfo() to fail with a single line error but got %v", err)
+	}
+}
+
+func TestParseShowSettings(t *testing.T) {
+	output := "Property settings:\n" +
+		"    file.encoding = UTF-8\n" +
+		"    java.home = /usr/lib/jvm/java-7\n" +
+		"    java.library.path = /usr/lib64\n" +
+		"        /lib64\n" +
+		"    java.vendor.url.bug = \n" +
+		"    os.arch = amd64\n" +
+		"\n" +
+		"java version \"1.7.0_80\"\n"
+	expected := map[string]string{
+		"java.home":           "/usr/lib/jvm/java-7",
+		"java.library.path":   "/usr/lib64:/lib64",
+		"java.vendor.url.bug": "",
+		"os.arch":             "amd64",
+	}
+	test.AssertEquals(t, "parseShowSettings()", expected, parseShowSettings(output, DefaultExtractedProperties))
+}
+
+func TestParseVersionBanner(t *testing.T) {
+	type TestData struct {
+		output   string
+		expected map[string]string
+	}
+	data := []TestData{{
+		output: "openjdk version \"17.0.9\" 2023-10-17\n" +
+			"OpenJDK Runtime Environment Temurin-17.0.9+9 (build 17.0.9+9)\n" +
+			"OpenJDK 64-Bit Server VM Temurin-17.0.9+9 (build 17.0.9+9, mixed mode, sharing)\n",
+		expected: map[string]string{
+			"java.version":               "17.0.9",
+			"java.specification.version": "17",
+			"java.runtime.name":          "OpenJDK Runtime Environment Temurin-17.0.9+9",
+			"java.runtime.version":       "17.0.9+9",
+			"sun.arch.data.model":        "64",
+		},
+	}, {
+		output: "java version \"1.6.0_45\"\n" +
+			"Java(TM) SE Runtime Environment (build 1.6.0_45-b06)\n" +
+			"Java HotSpot(TM) Client VM (build 20.45-b01, mixed mode)\n",
+		expected: map[string]string{
+			"java.version":               "1.6.0_45",
+			"java.specification.version": "1.6",
+			"java.runtime.name":          "Java(TM) SE Runtime Environment",
+			"java.runtime.version":       "1.6.0_45-b06",
+		},
+	}}
+	for _, data := range data {
+		actual, err := parseVersionBanner(data.output)
+		description := fmt.Sprintf("parseVersionBanner(%q)", data.output)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description, data.expected, actual)
+	}
+	_, err := parseVersionBanner("Error: could not open `lib/jvm.cfg'")
+	test.AssertErrorContains(t, "parseVersionBanner()", "no version found in the java -version banner", err)
+}
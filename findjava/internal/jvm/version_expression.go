@@ -0,0 +1,233 @@
+package jvm
+
+import (
+	"fmt"
+	"strings"
+)
+
+const unboundedMax = ^uint(0)
+
+// VersionMatcher matches Java specification versions.
+// It is implemented by VersionRange and VersionExpression.
+type VersionMatcher interface {
+	Matches(version uint) bool
+	// IsBounded returns false if every version matches.
+	IsBounded() bool
+	String() string
+}
+
+// VersionExpression is a VersionMatcher parsed from a textual expression such as "[11,17)", "11..21,!13",
+// ">=17" or "8|11|17|21".
+//
+// The expression is made of alternatives separated by '|', a version matching the expression if it matches any of
+// the alternatives. Each alternative is made of terms separated by ',', a version matching the alternative if it
+// matches all of its terms. A term can be negated with a leading '!' and is one of:
+//   - an exact version: 17
+//   - an inclusive range, any bound being optional: 11..17, 11.., ..17
+//   - a comparison: >=17, >17, <=17, <17, =17
+//   - a Maven range in which '[' and ']' are inclusive bounds, '(' and ')' exclusive ones,
+//     any bound being optional: [11,17), (8,], [17]
+type VersionExpression struct {
+	expression   string
+	alternatives [][]versionTerm
+}
+
+type versionTerm struct {
+	negated bool
+	min     uint
+	max     uint
+}
+
+func (term *versionTerm) matches(version uint) bool {
+	inRange := term.min <= version && version <= term.max
+	return inRange != term.negated
+}
+
+func (expression *VersionExpression) Matches(version uint) bool {
+	for _, alternative := range expression.alternatives {
+		if matchesAll(alternative, version) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(terms []versionTerm, version uint) bool {
+	for _, term := range terms {
+		if !term.matches(version) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsBounded returns false if every version matches. The terms being ranges, the versions between two consecutive
+// bounds of the terms either all match or none does, so checking the lowest version and every bound is enough.
+func (expression *VersionExpression) IsBounded() bool {
+	if !expression.Matches(1) {
+		return true
+	}
+	for _, alternative := range expression.alternatives {
+		for _, term := range alternative {
+			if !expression.Matches(term.min) || !expression.Matches(term.max) ||
+				(term.min > 1 && !expression.Matches(term.min-1)) ||
+				(term.max < unboundedMax && !expression.Matches(term.max+1)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (expression *VersionExpression) String() string {
+	return expression.expression
+}
+
+// ParseVersionExpression parses a version expression. See VersionExpression for the syntax.
+func ParseVersionExpression(expression string) (*VersionExpression, error) {
+	trimmed := strings.TrimSpace(expression)
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid version expression \"%s\": expression is empty", expression)
+	}
+	parsed := &VersionExpression{expression: trimmed}
+	for _, alternative := range splitTopLevel(trimmed, '|') {
+		var terms []versionTerm
+		for _, term := range splitTopLevel(alternative, ',') {
+			versionTerm, err := parseVersionTerm(strings.TrimSpace(term))
+			if err != nil {
+				return nil, fmt.Errorf("invalid version expression \"%s\": invalid term \"%s\": %w",
+					expression, strings.TrimSpace(term), err)
+			}
+			terms = append(terms, versionTerm)
+		}
+		parsed.alternatives = append(parsed.alternatives, terms)
+	}
+	return parsed, nil
+}
+
+// splitTopLevel splits the value on the separator, ignoring separators enclosed in brackets or parentheses.
+func splitTopLevel(value string, separator rune) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, c := range value {
+		switch c {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case separator:
+			if depth == 0 {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, value[start:])
+}
+
+func parseVersionTerm(term string) (versionTerm, error) {
+	if strings.HasPrefix(term, "!") {
+		negated, err := parseVersionTerm(strings.TrimSpace(term[1:]))
+		if err != nil {
+			return versionTerm{}, err
+		}
+		if negated.negated {
+			return versionTerm{}, fmt.Errorf("double negation is not supported")
+		}
+		negated.negated = true
+		return negated, nil
+	}
+	var result versionTerm
+	var err error
+	switch {
+	case term == "":
+		return versionTerm{}, fmt.Errorf("term is empty")
+	case strings.HasPrefix(term, "[") || strings.HasPrefix(term, "("):
+		result, err = parseMavenRange(term)
+	case strings.HasPrefix(term, ">="):
+		result.min, err = parseVersion(term[2:])
+		result.max = unboundedMax
+	case strings.HasPrefix(term, "<="):
+		result.max, err = parseVersion(term[2:])
+	case strings.HasPrefix(term, ">"):
+		result.min, err = parseVersion(term[1:])
+		result.min++
+		result.max = unboundedMax
+	case strings.HasPrefix(term, "<"):
+		result.max, err = parseVersion(term[1:])
+		if err == nil && result.max == 0 {
+			return versionTerm{}, fmt.Errorf("no version is lower than 0")
+		}
+		result.max--
+	case strings.HasPrefix(term, "="):
+		result.min, err = parseVersion(term[1:])
+		result.max = result.min
+	case strings.Contains(term, ".."):
+		bounds := strings.SplitN(term, "..", 2)
+		result.min, result.max, err = parseBounds(bounds[0], bounds[1])
+	default:
+		result.min, err = parseVersion(term)
+		result.max = result.min
+	}
+	if err != nil {
+		return versionTerm{}, err
+	}
+	if result.min > result.max {
+		return versionTerm{}, fmt.Errorf("range is empty")
+	}
+	return result, nil
+}
+
+func parseMavenRange(term string) (versionTerm, error) {
+	last := term[len(term)-1]
+	if len(term) < 2 || (last != ']' && last != ')') {
+		return versionTerm{}, fmt.Errorf("range must end with ']' or ')'")
+	}
+	bounds := strings.Split(term[1:len(term)-1], ",")
+	if len(bounds) == 1 {
+		if term[0] != '[' || last != ']' {
+			return versionTerm{}, fmt.Errorf("single version range must be written [version]")
+		}
+		version, err := parseVersion(bounds[0])
+		return versionTerm{min: version, max: version}, err
+	}
+	if len(bounds) != 2 {
+		return versionTerm{}, fmt.Errorf("range must have two bounds")
+	}
+	min, max, err := parseBounds(bounds[0], bounds[1])
+	if err != nil {
+		return versionTerm{}, err
+	}
+	if term[0] == '(' && strings.TrimSpace(bounds[0]) != "" {
+		min++
+	}
+	if last == ')' && strings.TrimSpace(bounds[1]) != "" {
+		if max == 0 {
+			return versionTerm{}, fmt.Errorf("no version is lower than 0")
+		}
+		max--
+	}
+	return versionTerm{min: min, max: max}, nil
+}
+
+func parseBounds(lower string, upper string) (uint, uint, error) {
+	min := uint(0)
+	max := unboundedMax
+	var err error
+	if lower = strings.TrimSpace(lower); lower != "" {
+		if min, err = parseVersion(lower); err != nil {
+			return 0, 0, err
+		}
+	}
+	if upper = strings.TrimSpace(upper); upper != "" {
+		if max, err = parseVersion(upper); err != nil {
+			return 0, 0, err
+		}
+	}
+	return min, max, nil
+}
+
+func parseVersion(version string) (uint, error) {
+	return ParseJavaSpecificationVersion(strings.TrimSpace(version))
+}
@@ -0,0 +1,90 @@
+package jvm
+
+import (
+	"findjava/test"
+	"fmt"
+	"testing"
+)
+
+func TestVersionExpressionMatches(t *testing.T) {
+	data := map[string][]uint{
+		"17":             {17},
+		"1.8":            {8},
+		"=11":            {11},
+		"11..17":         {11, 12, 13, 14, 15, 16, 17},
+		"..9":            {1, 2, 3, 4, 5, 6, 7, 8, 9},
+		"19..":           {19, 20, 21, 22, 23, 24},
+		">=21":           {21, 22, 23, 24},
+		">21":            {22, 23, 24},
+		"<=3":            {1, 2, 3},
+		"<3":             {1, 2},
+		"[11,17)":        {11, 12, 13, 14, 15, 16},
+		"(11,17]":        {12, 13, 14, 15, 16, 17},
+		"[20,)":          {20, 21, 22, 23, 24},
+		"(,4)":           {1, 2, 3},
+		"[1.8]":          {8},
+		"11..21,!13":     {11, 12, 14, 15, 16, 17, 18, 19, 20, 21},
+		"!2..23":         {1, 24},
+		"8|11|17|21":     {8, 11, 17, 21},
+		"[8,11)|[17,19)": {8, 9, 10, 17, 18},
+		" >=11 , <18 ":   {11, 12, 13, 14, 15, 16, 17},
+		"11..|!12..22":   {11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+	}
+	for expression, expectedVersions := range data {
+		parsed, err := ParseVersionExpression(expression)
+		description := fmt.Sprintf("ParseVersionExpression(\"%s\")", expression)
+		test.AssertNoError(t, description, err)
+		expected := make(map[uint]bool)
+		for _, version := range expectedVersions {
+			expected[version] = true
+		}
+		for version := uint(1); version < 25; version++ {
+			test.AssertEquals(t, fmt.Sprintf("%s.Matches(%d)", description, version), expected[version],
+				parsed.Matches(version))
+		}
+	}
+}
+
+func TestVersionExpressionIsBounded(t *testing.T) {
+	data := map[string]bool{
+		"17":           true,
+		">=17":         true,
+		"..21":         true,
+		"11..21,!13":   true,
+		"!17":          true,
+		"8|11..":       true,
+		">=1":          false,
+		"[1,)":         false,
+		"..16|17..":    false,
+		"11..|!12..22": false,
+	}
+	for expression, expected := range data {
+		parsed, err := ParseVersionExpression(expression)
+		description := fmt.Sprintf("ParseVersionExpression(\"%s\")", expression)
+		test.AssertNoError(t, description, err)
+		test.AssertEquals(t, description+".IsBounded()", expected, parsed.IsBounded())
+	}
+}
+
+func TestVersionExpressionErrors(t *testing.T) {
+	data := map[string]string{
+		"":         "invalid version expression \"\": expression is empty",
+		"abc":      "invalid version expression \"abc\": invalid term \"abc\": JVM version 'abc' cannot be parsed",
+		"11,,17":   "invalid version expression \"11,,17\": invalid term \"\": term is empty",
+		"17..11":   "invalid term \"17..11\": range is empty",
+		"[11,17":   "invalid term \"[11,17\": range must end with ']' or ')'",
+		"(17)":     "invalid term \"(17)\": single version range must be written [version]",
+		"[1,2,3]":  "invalid term \"[1,2,3]\": range must have two bounds",
+		"!!11":     "invalid term \"!!11\": double negation is not supported",
+		"<0":       "invalid term \"<0\": no version is lower than 0",
+		">=11|x":   "invalid term \"x\": JVM version 'x' cannot be parsed",
+		"[11,x)":   "invalid term \"[11,x)\": JVM version 'x' cannot be parsed",
+		">=-1":     "invalid term \">=-1\": JVM version '-1' cannot be parsed",
+		"11..17..": "invalid term \"11..17..\": JVM version '17..' cannot be parsed",
+	}
+	for expression, expected := range data {
+		_, err := ParseVersionExpression(expression)
+		description := fmt.Sprintf("ParseVersionExpression(\"%s\")", expression)
+		test.AssertErrorContains(t, description, expected, err)
+	}
+}
@@ -54,10 +54,17 @@ func Err(err error) {
 }
 
 func Die(err error) {
+	Exit(err, 1)
+}
+
+// Exit logs the error and exits with the given exit code.
+func Exit(err error, code int) {
 	Err(err)
-	os.Exit(1)
+	os.Exit(code)
 }
 
+// WrapErr prefixes the error message with a description of the failed operation.
+// The wrapped error can still be inspected with errors.Is and errors.As.
 func WrapErr(err error, message string, v ...interface{}) error {
-	return fmt.Errorf("%s\n\t%s", fmt.Sprintf(message, v...), err)
+	return fmt.Errorf("%s\n\t%w", fmt.Sprintf(message, v...), err)
 }
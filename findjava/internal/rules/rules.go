@@ -8,34 +8,98 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 type JvmSelectionRules struct {
-	VersionRange   *VersionRange
-	Vendors        utils.List
-	Programs       utils.List
+	VersionRange VersionMatcher
+	MinUpdates   []JavaVersion
+	Vendors      utils.List
+	Programs     utils.List
+	Archs        utils.List
+	DataModel    uint
+	// Properties are the constraints the system properties of the JVMs must satisfy.
+	Properties []*PropertyConstraint
+	// Filters are the filter expressions the JVMs must match.
+	Filters        []*FilterExpression
 	PreferredRules *JvmSelectionRules
+	// PreferredArchs are the architectures to prefer among the matching JVMs. Unlike the preferred rules,
+	// JVMs of other architectures are still selected when no JVM of a preferred architecture matches.
+	PreferredArchs []string
 }
 
 func (rules *JvmSelectionRules) String() string {
 	return fmt.Sprintf(`
     VersionRange: %v
+    MinUpdates: %v
     Vendors: %v
     Programs: %v
-    PreferredRules: %v`, rules.VersionRange, rules.Vendors, rules.Programs, rules.PreferredRules)
+    Archs: %v
+    DataModel: %d
+    Properties: %v
+    Filters: %v
+    PreferredRules: %v
+    PreferredArchs: %v`, rules.VersionRange, rules.MinUpdates, rules.Vendors, rules.Programs, rules.Archs,
+		rules.DataModel, rules.Properties, rules.Filters, rules.PreferredRules, rules.PreferredArchs)
 }
 
 func (rules *JvmSelectionRules) Matches(jvm *Jvm) bool {
+	return len(rules.Mismatches(jvm)) == 0
+}
+
+// Mismatches returns a description of every constraint the JVM does not satisfy.
+// An empty result means the JVM matches the rules.
+func (rules *JvmSelectionRules) Mismatches(jvm *Jvm) []string {
+	var mismatches []string
 	if !rules.VersionRange.Matches(jvm.JavaSpecificationVersion) {
-		return false
+		mismatches = append(mismatches, fmt.Sprintf("java.specification.version %d is not in range %s",
+			jvm.JavaSpecificationVersion, rules.VersionRange))
+	}
+	if minUpdate, ok := rules.matchMinUpdate(jvm); !ok {
+		mismatches = append(mismatches, fmt.Sprintf("java.version %s is lower than the minimum update %s",
+			jvm.JavaVersion, minUpdate))
 	}
 	if !rules.matchVendor(jvm) {
-		return false
+		mismatches = append(mismatches, fmt.Sprintf("java.vendor \"%s\" is not one of %v", jvm.JavaVendor, &rules.Vendors))
 	}
-	if !rules.matchPrograms(jvm) {
-		return false
+	if len(rules.Archs) > 0 && !containsArch(rules.Archs, jvm.OsArch) {
+		mismatches = append(mismatches, fmt.Sprintf("os.arch \"%s\" is not one of %v", jvm.OsArch, &rules.Archs))
 	}
-	return true
+	if rules.DataModel != 0 && jvm.DataModel != rules.DataModel {
+		mismatches = append(mismatches, fmt.Sprintf("sun.arch.data.model %d is not %d", jvm.DataModel, rules.DataModel))
+	}
+	mismatches = append(mismatches, rules.propertiesMismatches(jvm)...)
+	for _, filter := range rules.Filters {
+		for _, term := range filter.Mismatches(jvm) {
+			mismatches = append(mismatches, fmt.Sprintf("does not match the filter: %s", term))
+		}
+	}
+	return append(mismatches, rules.programsMismatches(jvm)...)
+}
+
+func (rules *JvmSelectionRules) propertiesMismatches(jvm *Jvm) []string {
+	var mismatches []string
+	for _, constraint := range rules.Properties {
+		if !constraint.Matches(jvm.SystemProperties) {
+			if value, ok := jvm.SystemProperties[constraint.Key]; ok {
+				mismatches = append(mismatches, fmt.Sprintf("%s \"%s\" does not satisfy %s", constraint.Key, value, constraint))
+			} else {
+				mismatches = append(mismatches, fmt.Sprintf("%s is not defined, required by %s", constraint.Key, constraint))
+			}
+		}
+	}
+	return mismatches
+}
+
+// matchMinUpdate checks the JVM version against the minimum update defined for its feature version, if any.
+// The minimum update is returned when it is not satisfied.
+func (rules *JvmSelectionRules) matchMinUpdate(jvm *Jvm) (JavaVersion, bool) {
+	for _, minUpdate := range rules.MinUpdates {
+		if minUpdate.Feature == jvm.JavaSpecificationVersion && jvm.JavaVersion.Compare(minUpdate) < 0 {
+			return minUpdate, false
+		}
+	}
+	return JavaVersion{}, true
 }
 
 func (rules *JvmSelectionRules) matchVendor(jvm *Jvm) bool {
@@ -50,36 +114,93 @@ func (rules *JvmSelectionRules) matchVendor(jvm *Jvm) bool {
 	return true
 }
 
-func (rules *JvmSelectionRules) matchPrograms(jvm *Jvm) bool {
+// MatchesPreferredArch returns true if the JVM architecture is one of the preferred ones
+// or if no architecture is preferred.
+func (rules *JvmSelectionRules) MatchesPreferredArch(jvm *Jvm) bool {
+	return len(rules.PreferredArchs) == 0 || containsArch(rules.PreferredArchs, jvm.OsArch)
+}
+
+func containsArch(archs []string, arch string) bool {
+	for _, candidate := range archs {
+		if NormalizeArch(candidate) == arch {
+			return true
+		}
+	}
+	return false
+}
+
+func (rules *JvmSelectionRules) programsMismatches(jvm *Jvm) []string {
+	var mismatches []string
 	for _, program := range rules.Programs {
 		if program != "java" {
 			programPath := filepath.Join(jvm.JavaHome, "bin", program)
 			if fileInfo, err := os.Stat(programPath); err == nil {
 				if fileInfo.Mode()&0111 == 0 {
 					log.Debug("Program %s is not executable", programPath)
-					return false
+					mismatches = append(mismatches, fmt.Sprintf("program %s is not executable", programPath))
 				}
 			} else {
 				log.Debug("Program %s not found", programPath)
-				return false
+				mismatches = append(mismatches, fmt.Sprintf("program %s not found", programPath))
 			}
 		}
 	}
-	return true
+	return mismatches
 }
 
 func SelectionRules(config *config.Config, minJavaVersion uint, maxJavaVersion uint, vendors utils.List, programs utils.List) *JvmSelectionRules {
-	rules := &JvmSelectionRules{}
-	rules.VersionRange = &VersionRange{
+	versionRange := &VersionRange{
 		Min: minJavaVersion,
 		Max: maxJavaVersion,
 	}
+	return SelectionRulesWithVersions(config, versionRange, vendors, programs)
+}
+
+// SelectionRulesWithVersions builds the selection rules for versions matching an arbitrary VersionMatcher,
+// for example a VersionExpression.
+func SelectionRulesWithVersions(config *config.Config, versions VersionMatcher, vendors utils.List, programs utils.List) *JvmSelectionRules {
+	rules := &JvmSelectionRules{}
+	rules.VersionRange = versions
 	rules.Vendors = vendors
 	rules.Programs = programs
+	rules.Properties = config.RequiredProperties
+	rules.Filters = filters(config.RequiredFilter)
 	rules.PreferredRules = &JvmSelectionRules{
-		VersionRange: &config.JvmVersionRange,
+		VersionRange: config.JvmVersionRange,
+		Properties:   config.PreferredProperties,
+		Filters:      filters(config.PreferredFilter),
 	}
+	rules.PreferredArchs = config.JvmPreferredArchs
 	//log.Debug("Requested version range: %v, preferred one: %v", rules.VersionRange, rules.preferredVersionRange)
 	log.Debug("Resolved matching rules %v", rules)
 	return rules
 }
+
+// ReferencedProperties returns the sorted system properties read by the property constraints and the filter
+// expressions of the rules and of their preferred rules.
+func (rules *JvmSelectionRules) ReferencedProperties() []string {
+	var properties []string
+	for current := rules; current != nil; current = current.PreferredRules {
+		for _, constraint := range current.Properties {
+			properties = append(properties, constraint.Key)
+		}
+		for _, filter := range current.Filters {
+			properties = append(properties, filter.Properties()...)
+		}
+	}
+	sort.Strings(properties)
+	var referenced []string
+	for i, property := range properties {
+		if i == 0 || property != properties[i-1] {
+			referenced = append(referenced, property)
+		}
+	}
+	return referenced
+}
+
+func filters(filter *FilterExpression) []*FilterExpression {
+	if filter == nil {
+		return nil
+	}
+	return []*FilterExpression{filter}
+}
@@ -12,9 +12,9 @@ func TestJvmSelectionRules(t *testing.T) {
 		minJavaVersion, maxJavaVersion uint
 	}
 	config := config.Config{
-		JvmVersionRange: VersionRange{Min: 11, Max: AllVersions},
+		JvmVersionRange: &VersionRange{Min: 11, Max: AllVersions},
 	}
-	preferredRules := &JvmSelectionRules{VersionRange: &config.JvmVersionRange}
+	preferredRules := &JvmSelectionRules{VersionRange: config.JvmVersionRange}
 	versionRangesToSelectionRules := map[TestData]JvmSelectionRules{
 		{minJavaVersion: 8, maxJavaVersion: 8}: {
 			VersionRange:   &VersionRange{Min: 8, Max: 8},
@@ -137,6 +137,103 @@ func TestJvmSelectionRulesMatches(t *testing.T) {
 	}
 }
 
+func TestJvmSelectionRulesReferencedProperties(t *testing.T) {
+	openJ9, _ := ParsePropertyConstraint("java.vm.name~=OpenJ9")
+	release, _ := ParsePropertyConstraint("jdk.debug=release")
+	filter, _ := ParseFilterExpression(`spec >= 17 && prop("java.vm.name") != "Zero" && has("java.vm.vendor")`)
+	rules := JvmSelectionRules{
+		Properties:     []*PropertyConstraint{openJ9},
+		PreferredRules: &JvmSelectionRules{Properties: []*PropertyConstraint{release}, Filters: []*FilterExpression{filter}},
+	}
+	expected := []string{"java.vm.name", "java.vm.vendor", "jdk.debug"}
+	if actual := rules.ReferencedProperties(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ReferencedProperties() = %v, expected %v", actual, expected)
+	}
+	if actual := (&JvmSelectionRules{}).ReferencedProperties(); actual != nil {
+		t.Errorf("ReferencedProperties() = %v, expected nil", actual)
+	}
+}
+
+func TestJvmSelectionRulesMismatches(t *testing.T) {
+	type TestData struct {
+		rules    JvmSelectionRules
+		jvmInfo  Jvm
+		expected []string
+	}
+	jvm17 := jvmWithVersion(17)
+	jvm17.JavaVendor = "Eclipse Adoptium"
+	jvm17.JavaVersion = JavaVersion{Feature: 17, Update: 8, Build: 7}
+	jvm17.OsArch = "amd64"
+	jvm17.DataModel = 64
+	jvm17.SystemProperties = map[string]string{"java.vm.name": "OpenJDK 64-Bit Server VM"}
+	openJ9, _ := ParsePropertyConstraint("java.vm.name~=OpenJ9")
+	notZero, _ := ParsePropertyConstraint("java.vm.name!~=Zero")
+	release, _ := ParsePropertyConstraint("jdk.debug=release")
+	filter, _ := ParseFilterExpression(`spec >= 17 && vendor ~= "Adoptium" && has("jdk.debug")`)
+	testData := []TestData{{
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{Min: 11, Max: 21}, Vendors: []string{"Eclipse Adoptium"}},
+		jvmInfo:  jvm17,
+		expected: nil,
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{Min: 21}},
+		jvmInfo:  jvm17,
+		expected: []string{"java.specification.version 17 is not in range [21..]"},
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{}, Vendors: []string{"Azul Systems, Inc.", "Oracle Corporation"}},
+		jvmInfo:  jvm17,
+		expected: []string{"java.vendor \"Eclipse Adoptium\" is not one of [Azul Systems, Inc., Oracle Corporation]"},
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{}, Programs: []string{"java", "javac"}},
+		jvmInfo:  jvm17,
+		expected: []string{"program /jvm/bin/javac not found"},
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{}, MinUpdates: []JavaVersion{{Feature: 17, Update: 9}}},
+		jvmInfo:  jvm17,
+		expected: []string{"java.version 17.0.8+7 is lower than the minimum update 17.0.9"},
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{}, MinUpdates: []JavaVersion{{Feature: 17, Update: 8}, {Feature: 21, Update: 1}}},
+		jvmInfo:  jvm17,
+		expected: nil,
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{}, Archs: []string{"x86_64"}, DataModel: 64},
+		jvmInfo:  jvm17,
+		expected: nil,
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{}, Archs: []string{"aarch64"}, DataModel: 32},
+		jvmInfo:  jvm17,
+		expected: []string{"os.arch \"amd64\" is not one of [aarch64]", "sun.arch.data.model 64 is not 32"},
+	}, {
+		rules:   JvmSelectionRules{VersionRange: &VersionRange{Max: 11}, Vendors: []string{"Oracle Corporation"}},
+		jvmInfo: jvm17,
+		expected: []string{
+			"java.specification.version 17 is not in range [..11]",
+			"java.vendor \"Eclipse Adoptium\" is not one of [Oracle Corporation]",
+		},
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{}, Properties: []*PropertyConstraint{notZero}},
+		jvmInfo:  jvm17,
+		expected: nil,
+	}, {
+		rules:   JvmSelectionRules{VersionRange: &VersionRange{}, Properties: []*PropertyConstraint{openJ9, notZero, release}},
+		jvmInfo: jvm17,
+		expected: []string{
+			"java.vm.name \"OpenJDK 64-Bit Server VM\" does not satisfy java.vm.name~=OpenJ9",
+			"jdk.debug is not defined, required by jdk.debug=release",
+		},
+	}, {
+		rules:    JvmSelectionRules{VersionRange: &VersionRange{}, Filters: []*FilterExpression{filter}},
+		jvmInfo:  jvm17,
+		expected: []string{"does not match the filter: has(\"jdk.debug\")"},
+	}}
+	for _, data := range testData {
+		mismatches := data.rules.Mismatches(&data.jvmInfo)
+		if !reflect.DeepEqual(mismatches, data.expected) {
+			t.Fatalf(`Expecting rules(%v).Mismatches("%v") == %#v but was %#v`,
+				data.rules, data.jvmInfo, data.expected, mismatches)
+		}
+	}
+}
+
 func jvmWithVersion(version uint) Jvm {
 	return Jvm{
 		JavaHome:                 "/jvm",
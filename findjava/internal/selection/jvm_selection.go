@@ -4,49 +4,156 @@ import (
 	. "findjava/internal/jvm"
 	"findjava/internal/log"
 	"findjava/internal/rules"
+	"fmt"
 	"sort"
 )
 
+const StatusSelected = "selected"
+const StatusCandidate = "candidate"
+const StatusIgnored = "ignored"
+
+// Explanation describes the outcome of the selection for a single JVM.
+// Reasons lists the constraints the JVM failed to satisfy, it is empty for candidates.
+type Explanation struct {
+	Jvm     Jvm
+	Status  string
+	Reasons []string
+}
+
+// Report describes the outcome of the selection for every JVM.
+// Explanations are sorted by status (selected, candidates, ignored) and then by order of preference.
+type Report struct {
+	Explanations []Explanation
+	// PreferredRulesIgnored is true when the preferred rules coming from the configuration
+	// could not be satisfied and were ignored in favor of the strong rules only.
+	PreferredRulesIgnored bool
+}
+
+// NoMatchingJvmError is returned when no JVM satisfies the selection rules.
+type NoMatchingJvmError struct {
+	Rules *rules.JvmSelectionRules
+}
+
+func (e *NoMatchingJvmError) Error() string {
+	return fmt.Sprintf("unable to find a JVM matching requirements %s", e.Rules)
+}
+
+type result struct {
+	candidates            []Jvm
+	ignored               []Jvm
+	reasons               map[string][]string
+	preferredRulesIgnored bool
+}
+
 func Select(rules *rules.JvmSelectionRules, jvms *JvmsInfos) []Jvm {
-	candidates, ignored := filter(rules, jvms)
-	sort.Slice(ignored[:], func(i, j int) bool { return sortCandidates(ignored, i, j) })
-	sort.Slice(candidates[:], func(i, j int) bool { return sortCandidates(candidates, i, j) })
+	candidates, ignored := Classify(rules, jvms)
 	LogJvmList("[IGNORED]", ignored)
 	LogJvmList("[CANDIDATE]", candidates)
 	return candidates
 }
 
-func filter(rules *rules.JvmSelectionRules, jvms *JvmsInfos) ([]Jvm, []Jvm) {
+// Classify splits the JVMs into candidates and ignored ones according to the rules.
+// Both lists are sorted by order of preference, the first candidate being the one Select would pick.
+func Classify(rules *rules.JvmSelectionRules, jvms *JvmsInfos) ([]Jvm, []Jvm) {
+	res := classify(rules, jvms)
+	return res.candidates, res.ignored
+}
+
+// Explain runs the selection and reports why each JVM has been selected, kept as candidate or ignored.
+func Explain(rules *rules.JvmSelectionRules, jvms *JvmsInfos) Report {
+	res := classify(rules, jvms)
+	report := Report{PreferredRulesIgnored: res.preferredRulesIgnored}
+	for i, jvm := range res.candidates {
+		status := StatusCandidate
+		if i == 0 {
+			status = StatusSelected
+		}
+		report.Explanations = append(report.Explanations, Explanation{Jvm: jvm, Status: status})
+	}
+	for _, jvm := range res.ignored {
+		report.Explanations = append(report.Explanations, Explanation{
+			Jvm:     jvm,
+			Status:  StatusIgnored,
+			Reasons: res.reasons[jvm.JavaPath()],
+		})
+	}
+	return report
+}
+
+// Sort sorts the JVMs by order of preference.
+func Sort(jvms []Jvm) {
+	sort.Slice(jvms[:], func(i, j int) bool { return sortCandidates(jvms, i, j) })
+}
+
+func classify(rules *rules.JvmSelectionRules, jvms *JvmsInfos) result {
 	var allJvms []Jvm
 	for _, jvm := range jvms.Jvms {
 		allJvms = append(allJvms, *jvm)
 	}
-	return filterJvmList(rules, allJvms)
+	res := result{reasons: make(map[string][]string)}
+	res.candidates, res.ignored = filterJvmList(rules, allJvms, "", &res)
+	res.candidates, res.ignored = preferArchs(rules, res.candidates, res.ignored, &res)
+	Sort(res.ignored)
+	Sort(res.candidates)
+	return res
 }
 
-func filterJvmList(rules *rules.JvmSelectionRules, allJvms []Jvm) ([]Jvm, []Jvm) {
+func filterJvmList(rules *rules.JvmSelectionRules, allJvms []Jvm, reasonPrefix string, res *result) ([]Jvm, []Jvm) {
 	var candidates []Jvm
 	var ignored []Jvm
 	for _, jvm := range allJvms {
-		if rules.Matches(&jvm) {
+		if mismatches := rules.Mismatches(&jvm); len(mismatches) == 0 {
 			candidates = append(candidates, jvm)
 		} else {
 			ignored = append(ignored, jvm)
+			for _, mismatch := range mismatches {
+				res.reasons[jvm.JavaPath()] = append(res.reasons[jvm.JavaPath()], reasonPrefix+mismatch)
+			}
 		}
 	}
 	if len(candidates) > 0 && rules.PreferredRules != nil {
-		preferredCandidates, preferredIgnored := filterJvmList(rules.PreferredRules, candidates)
+		preferredCandidates, preferredIgnored := filterJvmList(rules.PreferredRules, candidates,
+			reasonPrefix+"preferred rules from configuration: ", res)
 		if len(preferredCandidates) > 0 {
-			return preferredCandidates, preferredIgnored
+			return preferredCandidates, append(ignored, preferredIgnored...)
 		} else if !rules.VersionRange.IsBounded() {
-			return nil, preferredIgnored
+			return nil, append(ignored, preferredIgnored...)
 		} else {
 			log.Info("Unable to satisfy preferred selection rules %v, ignoring them", rules.PreferredRules)
+			for _, jvm := range preferredIgnored {
+				delete(res.reasons, jvm.JavaPath())
+			}
+			res.preferredRulesIgnored = true
 		}
 	}
 	return candidates, ignored
 }
 
+// preferArchs keeps the candidates of the preferred architectures, if any,
+// the candidates of other architectures being only kept when none of them matches.
+func preferArchs(rules *rules.JvmSelectionRules, candidates []Jvm, ignored []Jvm, res *result) ([]Jvm, []Jvm) {
+	var preferred []Jvm
+	var others []Jvm
+	for _, jvm := range candidates {
+		if rules.MatchesPreferredArch(&jvm) {
+			preferred = append(preferred, jvm)
+		} else {
+			others = append(others, jvm)
+		}
+	}
+	if len(preferred) == 0 {
+		if len(others) > 0 {
+			log.Info("No JVM matches the preferred architectures %v, ignoring them", rules.PreferredArchs)
+		}
+		return candidates, ignored
+	}
+	for _, jvm := range others {
+		res.reasons[jvm.JavaPath()] = append(res.reasons[jvm.JavaPath()],
+			fmt.Sprintf("os.arch \"%s\" is not one of the preferred architectures %v", jvm.OsArch, rules.PreferredArchs))
+	}
+	return preferred, append(ignored, others...)
+}
+
 func LogJvmList(displayType string, jvms []Jvm) {
 	for i := len(jvms) - 1; i >= 0; i = i - 1 {
 		jvm := jvms[i]
@@ -56,6 +163,9 @@ func LogJvmList(displayType string, jvms []Jvm) {
 
 func sortCandidates(jvms []Jvm, i int, j int) bool {
 	if jvms[i].JavaSpecificationVersion == jvms[j].JavaSpecificationVersion {
+		if comparison := jvms[i].JavaVersion.Compare(jvms[j].JavaVersion); comparison != 0 {
+			return comparison > 0
+		}
 		return jvms[i].JavaHome > jvms[j].JavaHome
 	}
 	return jvms[i].JavaSpecificationVersion > jvms[j].JavaSpecificationVersion
@@ -0,0 +1,237 @@
+package selection
+
+import (
+	"encoding/json"
+	. "findjava/internal/jvm"
+	"findjava/internal/rules"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// explained is the part of an Explanation the tests check, the JVM being identified by its java executable.
+type explained struct {
+	javaPath string
+	status   string
+	reasons  []string
+}
+
+var testJvms = map[string]map[string]string{
+	"/jvm/11/bin/java": jvmProperties("/jvm/11", "11", "11.0.20", "Eclipse Adoptium", "amd64"),
+	"/jvm/17/bin/java": jvmProperties("/jvm/17", "17", "17.0.9", "Eclipse Adoptium", "amd64"),
+	"/jvm/21/bin/java": jvmProperties("/jvm/21", "21", "21.0.1", "Azul Systems, Inc.", "amd64"),
+}
+
+func TestExplain(t *testing.T) {
+	type TestData struct {
+		rules                 rules.JvmSelectionRules
+		expected              []explained
+		preferredRulesIgnored bool
+	}
+	atLeast17, _ := ParseVersionExpression(">=17")
+	testData := []TestData{{
+		rules: rules.JvmSelectionRules{VersionRange: &VersionRange{Min: 17}},
+		expected: []explained{
+			{"/jvm/21/bin/java", StatusSelected, nil},
+			{"/jvm/17/bin/java", StatusCandidate, nil},
+			{"/jvm/11/bin/java", StatusIgnored, []string{"java.specification.version 11 is not in range [17..]"}},
+		},
+	}, {
+		rules: rules.JvmSelectionRules{VersionRange: &VersionRange{Max: 17}, Vendors: []string{"Azul Systems, Inc."}},
+		expected: []explained{
+			{"/jvm/21/bin/java", StatusIgnored, []string{"java.specification.version 21 is not in range [..17]"}},
+			{"/jvm/17/bin/java", StatusIgnored, []string{
+				"java.vendor \"Eclipse Adoptium\" is not one of [Azul Systems, Inc.]",
+			}},
+			{"/jvm/11/bin/java", StatusIgnored, []string{
+				"java.vendor \"Eclipse Adoptium\" is not one of [Azul Systems, Inc.]",
+			}},
+		},
+	}, {
+		rules: rules.JvmSelectionRules{
+			VersionRange:   &VersionRange{Min: 11},
+			PreferredRules: &rules.JvmSelectionRules{VersionRange: &VersionRange{Max: 17}},
+		},
+		expected: []explained{
+			{"/jvm/17/bin/java", StatusSelected, nil},
+			{"/jvm/11/bin/java", StatusCandidate, nil},
+			{"/jvm/21/bin/java", StatusIgnored, []string{
+				"preferred rules from configuration: java.specification.version 21 is not in range [..17]",
+			}},
+		},
+	}, {
+		rules: rules.JvmSelectionRules{
+			VersionRange:   &VersionRange{Min: 17},
+			PreferredRules: &rules.JvmSelectionRules{VersionRange: &VersionRange{Max: 11}},
+		},
+		expected: []explained{
+			{"/jvm/21/bin/java", StatusSelected, nil},
+			{"/jvm/17/bin/java", StatusCandidate, nil},
+			{"/jvm/11/bin/java", StatusIgnored, []string{"java.specification.version 11 is not in range [17..]"}},
+		},
+		preferredRulesIgnored: true,
+	}, {
+		rules: rules.JvmSelectionRules{
+			VersionRange:   atLeast17,
+			PreferredRules: &rules.JvmSelectionRules{VersionRange: &VersionRange{Max: 11}},
+		},
+		expected: []explained{
+			{"/jvm/21/bin/java", StatusSelected, nil},
+			{"/jvm/17/bin/java", StatusCandidate, nil},
+			{"/jvm/11/bin/java", StatusIgnored, []string{"java.specification.version 11 is not in range >=17"}},
+		},
+		preferredRulesIgnored: true,
+	}, {
+		rules: rules.JvmSelectionRules{
+			VersionRange:   &VersionRange{},
+			PreferredRules: &rules.JvmSelectionRules{VersionRange: &VersionRange{Max: 8}},
+		},
+		expected: []explained{
+			{"/jvm/21/bin/java", StatusIgnored, []string{
+				"preferred rules from configuration: java.specification.version 21 is not in range [..8]",
+			}},
+			{"/jvm/17/bin/java", StatusIgnored, []string{
+				"preferred rules from configuration: java.specification.version 17 is not in range [..8]",
+			}},
+			{"/jvm/11/bin/java", StatusIgnored, []string{
+				"preferred rules from configuration: java.specification.version 11 is not in range [..8]",
+			}},
+		},
+	}}
+	jvms := loadJvms(t, testJvms)
+	for _, data := range testData {
+		report := Explain(&data.rules, jvms)
+		if actual := explanations(report); !reflect.DeepEqual(actual, data.expected) {
+			t.Fatalf("Expecting Explain(%v) == %#v but was %#v", &data.rules, data.expected, actual)
+		}
+		if report.PreferredRulesIgnored != data.preferredRulesIgnored {
+			t.Fatalf("Expecting Explain(%v).PreferredRulesIgnored == %t but was %t", &data.rules,
+				data.preferredRulesIgnored, report.PreferredRulesIgnored)
+		}
+		var expectedSelection []string
+		if data.expected[0].status == StatusSelected {
+			expectedSelection = []string{data.expected[0].javaPath}
+		}
+		var selection []string
+		if selected := Select(&data.rules, jvms); len(selected) > 0 {
+			selection = []string{selected[0].JavaPath()}
+		}
+		if !reflect.DeepEqual(selection, expectedSelection) {
+			t.Fatalf("Expecting Select(%v) to select %v but was %v", &data.rules, expectedSelection, selection)
+		}
+	}
+}
+
+// loadJvms loads the JVMs described by their system properties and indexed by their java executable from a cache,
+// the java executable of a Jvm being only set when it is loaded.
+func loadJvms(t *testing.T, jvms map[string]map[string]string) *JvmsInfos {
+	directory, err := ioutil.TempDir("", "findjava-")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(directory) }()
+	cached := JvmsInfos{Jvms: make(map[string]*Jvm)}
+	for javaPath, properties := range jvms {
+		cached.Jvms[javaPath] = &Jvm{SystemProperties: properties}
+	}
+	content, _ := json.Marshal(cached)
+	path := filepath.Join(directory, "findjava.json")
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("Unable to write the cache %s: %v", path, err)
+	}
+	loaded := LoadCache(path)
+	if len(loaded.Jvms) != len(jvms) {
+		t.Fatalf("Expecting %d JVMs to be loaded from %s but got %d", len(jvms), path, len(loaded.Jvms))
+	}
+	return &loaded
+}
+
+func jvmProperties(javaHome string, specificationVersion string, version string, vendor string,
+	arch string) map[string]string {
+	return map[string]string{
+		"java.home":                  javaHome,
+		"java.specification.version": specificationVersion,
+		"java.version":               version,
+		"java.vendor":                vendor,
+		"os.arch":                    arch,
+		"sun.arch.data.model":        "64",
+	}
+}
+
+func explanations(report Report) []explained {
+	var result []explained
+	for _, explanation := range report.Explanations {
+		result = append(result, explained{explanation.Jvm.JavaPath(), explanation.Status, explanation.Reasons})
+	}
+	return result
+}
+
+func TestExplainPreferredArchs(t *testing.T) {
+	type TestData struct {
+		rules    rules.JvmSelectionRules
+		expected []explained
+	}
+	host := HostArch()
+	other := "aarch64"
+	if host == other {
+		other = "amd64"
+	}
+	jvms := map[string]map[string]string{
+		"/jvm/21-host/bin/java":  jvmProperties("/jvm/21-host", "21", "21.0.1", "Eclipse Adoptium", host),
+		"/jvm/21-other/bin/java": jvmProperties("/jvm/21-other", "21", "21.0.1", "Eclipse Adoptium", other),
+		"/jvm/17-x86/bin/java":   jvmProperties("/jvm/17-x86", "17", "17.0.9", "Eclipse Adoptium", "x86"),
+	}
+	jvms["/jvm/17-x86/bin/java"]["sun.arch.data.model"] = "32"
+	testData := []TestData{{
+		// Without preference, the JVMs of the same version are sorted by java.home
+		rules: rules.JvmSelectionRules{VersionRange: &VersionRange{Min: 21}},
+		expected: []explained{
+			{"/jvm/21-other/bin/java", StatusSelected, nil},
+			{"/jvm/21-host/bin/java", StatusCandidate, nil},
+			{"/jvm/17-x86/bin/java", StatusIgnored, []string{"java.specification.version 17 is not in range [21..]"}},
+		},
+	}, {
+		rules: rules.JvmSelectionRules{VersionRange: &VersionRange{Min: 21}, PreferredArchs: []string{host}},
+		expected: []explained{
+			{"/jvm/21-host/bin/java", StatusSelected, nil},
+			{"/jvm/21-other/bin/java", StatusIgnored, []string{
+				"os.arch \"" + other + "\" is not one of the preferred architectures [" + host + "]",
+			}},
+			{"/jvm/17-x86/bin/java", StatusIgnored, []string{"java.specification.version 17 is not in range [21..]"}},
+		},
+	}, {
+		// The preferred architectures only apply to the JVMs satisfying the data model
+		rules: rules.JvmSelectionRules{VersionRange: &VersionRange{}, DataModel: 32, PreferredArchs: []string{host}},
+		expected: []explained{
+			{"/jvm/17-x86/bin/java", StatusSelected, nil},
+			{"/jvm/21-other/bin/java", StatusIgnored, []string{"sun.arch.data.model 64 is not 32"}},
+			{"/jvm/21-host/bin/java", StatusIgnored, []string{"sun.arch.data.model 64 is not 32"}},
+		},
+	}, {
+		rules: rules.JvmSelectionRules{VersionRange: &VersionRange{}, DataModel: 64, PreferredArchs: []string{"x86"}},
+		expected: []explained{
+			{"/jvm/21-other/bin/java", StatusSelected, nil},
+			{"/jvm/21-host/bin/java", StatusCandidate, nil},
+			{"/jvm/17-x86/bin/java", StatusIgnored, []string{"sun.arch.data.model 32 is not 64"}},
+		},
+	}, {
+		rules: rules.JvmSelectionRules{VersionRange: &VersionRange{}, PreferredArchs: []string{"x86"}},
+		expected: []explained{
+			{"/jvm/17-x86/bin/java", StatusSelected, nil},
+			{"/jvm/21-other/bin/java", StatusIgnored, []string{
+				"os.arch \"" + other + "\" is not one of the preferred architectures [x86]",
+			}},
+			{"/jvm/21-host/bin/java", StatusIgnored, []string{
+				"os.arch \"" + host + "\" is not one of the preferred architectures [x86]",
+			}},
+		},
+	}}
+	loaded := loadJvms(t, jvms)
+	for _, data := range testData {
+		if actual := explanations(Explain(&data.rules, loaded)); !reflect.DeepEqual(actual, data.expected) {
+			t.Fatalf("Expecting Explain(%v) == %#v but was %#v", &data.rules, data.expected, actual)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"findjava/internal/log"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 func CreateDirectory(path string) error {
@@ -20,6 +22,31 @@ func WriteFile(name string, data []byte, perm os.FileMode) error {
 	return err
 }
 
+// WriteFileAtomic writes the data to a temporary file of the same directory and renames it to the given name,
+// so that readers see either the previous content or the new one, never a partially written file.
+func WriteFileAtomic(name string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name)+".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if err1 := f.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
 func CloseFile(file *os.File) {
 	err := file.Close()
 	if err != nil {
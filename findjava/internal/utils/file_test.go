@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"findjava/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	path := filepath.Join(directory, "findjava.json")
+	for _, content := range []string{"first content", "second"} {
+		test.AssertNoError(t, "WriteFileAtomic()", WriteFileAtomic(path, []byte(content), 0644))
+		actual, err := ioutil.ReadFile(path)
+		test.AssertNoError(t, "ioutil.ReadFile()", err)
+		test.AssertEquals(t, "ioutil.ReadFile()", content, string(actual))
+	}
+	files, err := ioutil.ReadDir(directory)
+	test.AssertNoError(t, "ioutil.ReadDir()", err)
+	test.AssertEquals(t, "len(ioutil.ReadDir())", 1, len(files))
+	test.AssertEquals(t, "ioutil.ReadDir()[0].Mode()", os.FileMode(0644), files[0].Mode())
+}
+
+func TestLockFile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "findjava-")
+	test.AssertNoError(t, "ioutil.TempDir()", err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	path := filepath.Join(directory, "findjava.json.lock")
+	first, err := LockFile(path, false)
+	test.AssertNoError(t, "LockFile(shared)", err)
+	second, err := LockFile(path, false)
+	test.AssertNoError(t, "LockFile(shared)", err)
+	first.Unlock()
+	second.Unlock()
+	exclusive, err := LockFile(path, true)
+	test.AssertNoError(t, "LockFile(exclusive)", err)
+	exclusive.Unlock()
+	var nilLock *FileLock
+	nilLock.Unlock()
+}
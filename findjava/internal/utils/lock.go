@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"findjava/internal/log"
+	"os"
+	"syscall"
+)
+
+// FileLock is an advisory lock (flock) held on a lock file.
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile acquires an advisory lock on the given lock file, creating it if needed, and blocks until the lock
+// is acquired. Exclusive locks are meant for writers and shared locks for readers.
+func LockFile(path string, exclusive bool) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(file.Fd()), how); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &FileLock{file: file}, nil
+}
+
+// Unlock releases the lock. Unlocking a nil lock does nothing so that callers can proceed without lock
+// when it cannot be acquired.
+func (lock *FileLock) Unlock() {
+	if lock == nil {
+		return
+	}
+	if err := syscall.Flock(int(lock.file.Fd()), syscall.LOCK_UN); err != nil {
+		log.Warn(log.WrapErr(err, "unable to unlock %s", lock.file.Name()))
+	}
+	_ = lock.file.Close()
+}
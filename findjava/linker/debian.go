@@ -6,5 +6,6 @@ package linker
 func init() {
 	setConfigDir("/etc/findjava/")
 	setCacheDir("~/.cache/findjava/")
+	setSystemCacheDir("/var/cache/findjava/")
 	setMetadataExtractorDir("/usr/share/findjava/metadata-extractor")
 }
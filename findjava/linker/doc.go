@@ -6,6 +6,7 @@ The following variables can be overridden at build time thanks to go ldflags:
 
   - [ConfigDir]: the path to the directory holding the findjava configuration.
   - [CacheDir]: the path to the directory in which findjava will cache the JVM metadata.
+  - [SystemCacheDir]: the path to the directory holding the read-only system-wide JVM metadata cache.
   - [MetadataExtractorDir]: the path to the directory holding the JVM metadata extractor.
 
 To override one variable, set a `-X` variable definition via go build -ldflags:
@@ -27,6 +28,7 @@ To activate a configuration, set a go build tag with the configuration name when
 Note: it is is possible to activate a configuration and then override individual values via ldflags.
 
 If none of those configuration is specified at build time, the built binary will be a development one.
-See [defaultConfigDir], [defaultCacheDir] and [defaultMetadataExtractorDir] for the development values.
+See [defaultConfigDir], [defaultCacheDir], [defaultMetadataExtractorDir] and [defaultSystemCacheDir] for the
+development values.
 */
 package linker
@@ -3,6 +3,7 @@ package linker
 const defaultConfigDir = "../"
 const defaultCacheDir = "../"
 const defaultMetadataExtractorDir = "./metadata-extractor/"
+const defaultSystemCacheDir = ""
 
 // ConfigDir is the path to the directory holding the findjava configuration.
 // Can be absolute, relative to the user's home (starts with ~),
@@ -19,6 +20,11 @@ var CacheDir = defaultCacheDir
 // or relative to the findjava binary's directory.
 var MetadataExtractorDir = defaultMetadataExtractorDir
 
+// SystemCacheDir is the path to the directory holding the system-wide JVM metadata cache maintained by the
+// package manager, which is consulted before the user's cache. Must be absolute.
+// The system cache is disabled when empty.
+var SystemCacheDir = defaultSystemCacheDir
+
 func setConfigDir(value string) {
 	setIfNotOverridden(&ConfigDir, defaultConfigDir, value)
 }
@@ -31,6 +37,10 @@ func setMetadataExtractorDir(value string) {
 	setIfNotOverridden(&MetadataExtractorDir, defaultMetadataExtractorDir, value)
 }
 
+func setSystemCacheDir(value string) {
+	setIfNotOverridden(&SystemCacheDir, defaultSystemCacheDir, value)
+}
+
 func setIfNotOverridden(variable *string, defaultValue string, newValue string) {
 	if *variable == defaultValue {
 		*variable = newValue
@@ -1,19 +1,93 @@
+import java.util.Arrays;
+import java.util.List;
+import java.util.stream.Collectors;
 
+/**
+ * Prints the JVM system properties needed by findjava on the standard output, as a JSON document between
+ * {@link #BEGIN_MARKER} and {@link #END_MARKER} lines so that findjava can ignore whatever else the JVM prints:
+ *
+ * <pre>
+ * {"protocolVersion":1,"properties":{"java.home":"/usr/lib/jvm/java-17",...}}
+ * </pre>
+ *
+ * Non-ASCII characters are escaped so that the output does not depend on the encoding of the standard output.
+ * <p>
+ * The arguments are the patterns of the properties to print, a pattern ending with {@code *} matching every property
+ * starting with the pattern without the {@code *}. Without arguments, {@link #DEFAULT_PATTERNS} are used.
+ */
 public class JvmMetadataExtractor {
 
+    /**
+     * The version of the output format, to be incremented whenever findjava can no longer parse it.
+     */
+    private static final int PROTOCOL_VERSION = 1;
+
+    private static final String BEGIN_MARKER = "--- findjava metadata begin ---";
+    private static final String END_MARKER = "--- findjava metadata end ---";
+
+    private static final List<String> DEFAULT_PATTERNS = Arrays.asList("java.*", "os.arch", "sun.arch.data.model");
+
     public static void main(String[] args) {
-        System
+        List<String> patterns = args.length > 0 ? Arrays.asList(args) : DEFAULT_PATTERNS;
+        List<String> properties = System
             .getProperties()
             .keySet()
             .stream()
             .filter(String.class::isInstance)
             .map(String.class::cast)
-            .filter(key -> key.startsWith("java."))
+            .filter(property -> isExtracted(patterns, property))
             .sorted()
-            .forEach(JvmMetadataExtractor::printProperty);
+            .collect(Collectors.toList());
+        StringBuilder json = new StringBuilder();
+        json.append("{\"protocolVersion\":").append(PROTOCOL_VERSION).append(",\"properties\":{");
+        boolean first = true;
+        for (String property : properties) {
+            String value = System.getProperty(property);
+            if (value == null) {
+                continue;
+            }
+            if (!first) {
+                json.append(',');
+            }
+            first = false;
+            appendString(json, property);
+            json.append(':');
+            appendString(json, value);
+        }
+        json.append("}}");
+        // The markers must start a line even when the JVM printed something without a trailing line separator
+        System.out.print('\n');
+        System.out.print(BEGIN_MARKER + '\n');
+        System.out.print(json.toString() + '\n');
+        System.out.print(END_MARKER + '\n');
+        System.out.flush();
+    }
+
+    private static boolean isExtracted(List<String> patterns, String property) {
+        for (String pattern : patterns) {
+            if (pattern.endsWith("*")
+                ? property.startsWith(pattern.substring(0, pattern.length() - 1))
+                : property.equals(pattern)) {
+                return true;
+            }
+        }
+        return false;
     }
 
-    private static void printProperty(String property) {
-        System.out.printf("%s=%s%n", property, System.getProperty(property));
+    private static void appendString(StringBuilder json, String value) {
+        json.append('"');
+        for (int i = 0; i < value.length(); i++) {
+            char c = value.charAt(i);
+            if (c == '"') {
+                json.append("\\\"");
+            } else if (c == '\\') {
+                json.append("\\\\");
+            } else if (c < 0x20 || c > 0x7e) {
+                json.append(String.format("\\u%04x", (int) c));
+            } else {
+                json.append(c);
+            }
+        }
+        json.append('"');
     }
 }
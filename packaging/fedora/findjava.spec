@@ -22,12 +22,12 @@ and what kind of features (java, javac, native-image, ...) it should provide.
 
 %setup -q -n findjava-${version}
 %build
-GO_LD_FLAGS='-linkmode=external' GO_TAGS="-tags linux" make test build
+GO_LD_FLAGS="-linkmode=external -X 'findjava/linker.SystemCacheDir=/var/cache/%{name}/'" GO_TAGS="-tags linux" make test build
 
 %install
 %define distdir build
 find .
-mkdir -p %{buildroot}/usr/bin %{buildroot}/usr/share/%{name} %{buildroot}/usr/share/%{name}/metadata-extractor %{buildroot}/etc/%{name}
+mkdir -p %{buildroot}/usr/bin %{buildroot}/usr/share/%{name} %{buildroot}/usr/share/%{name}/metadata-extractor %{buildroot}/etc/%{name} %{buildroot}/var/cache/%{name}
 ln -s ../share/%{name}/%{name} %{buildroot}/usr/bin/%{name}
 install -p -m 755 %{distdir}/dist/%{name} %{buildroot}/usr/share/%{name}/%{name}
 install -p -m 644 %{distdir}/dist/metadata-extractor/JvmMetadataExtractor.class %{buildroot}/usr/share/%{name}/metadata-extractor/JvmMetadataExtractor.class
@@ -39,3 +39,16 @@ install -p -m 644 packaging/fedora/config.conf %{buildroot}/etc/%{name}/config.c
 /usr/share/%{name}/%{name}
 /usr/share/%{name}/metadata-extractor/JvmMetadataExtractor.class
 /etc/%{name}/config.conf
+%dir /var/cache/%{name}
+%ghost /var/cache/%{name}/%{name}.json
+%ghost /var/cache/%{name}/%{name}.json.lock
+
+# Rebuilds the system cache on installation and whenever a JDK package is installed or removed
+%post
+%{_bindir}/%{name} cache rebuild --system || :
+
+%filetriggerin -- /usr/lib/jvm
+%{_bindir}/%{name} cache rebuild --system || :
+
+%filetriggerpostun -- /usr/lib/jvm
+%{_bindir}/%{name} cache rebuild --system || :